@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// archiveVersion アーカイブの形式のバージョン
+const archiveVersion = 1
+
+// bookArchive Book単位のアーカイブ（表紙・挿絵・添付ファイルなどのファイル本体は含まない）
+type bookArchive struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Book       models.Book       `json:"book"`
+	Episodes   []models.Episode  `json:"episodes"`
+	Materials  []models.Material `json:"materials"`
+}
+
+// runExport BookとそのエピソードとBook固有の参考資料をJSONに書き出す
+func runExport(db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	bookFlag := fs.String("book", "", "書き出すBookのID（必須）")
+	out := fs.String("out", "", "出力先のファイル（省略時は標準出力）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	bookID, err := uuid.Parse(*bookFlag)
+	if err != nil {
+		return fmt.Errorf("invalid book ID: %w", err)
+	}
+
+	archive := bookArchive{Version: archiveVersion, ExportedAt: time.Now().UTC()}
+	if err := db.Where("id = ?", bookID).First(&archive.Book).Error; err != nil {
+		return fmt.Errorf("failed to fetch book: %w", err)
+	}
+	if err := db.Where("book_id = ?", bookID).Order("episode_no").Find(&archive.Episodes).Error; err != nil {
+		return fmt.Errorf("failed to fetch episodes: %w", err)
+	}
+	if err := db.Where("book_id = ?", bookID).Order("created_at").Find(&archive.Materials).Error; err != nil {
+		return fmt.Errorf("failed to fetch materials: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(&archive); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %q: %d episodes, %d materials\n", archive.Book.Title, len(archive.Episodes), len(archive.Materials))
+	return nil
+}
+
+// runImport アーカイブからBookを取り込む（-new-ids で新しいIDを振り直して複製する）
+func runImport(db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	in := fs.String("in", "", "取り込むアーカイブのファイル（必須）")
+	newIDs := fs.Bool("new-ids", false, "新しいIDを振り直して取り込む")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return errors.New("-in is required")
+	}
+
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var archive bookArchive
+	if err := json.NewDecoder(f).Decode(&archive); err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	if archive.Version != archiveVersion {
+		return fmt.Errorf("unsupported archive version %d", archive.Version)
+	}
+
+	// ファイル本体はアーカイブに含まれないため表紙は取り込まない
+	book := archive.Book
+	book.Episodes = nil
+	book.Materials = nil
+	book.Stats = nil
+	book.CoverImage = ""
+	book.CoverThumb = ""
+	if *newIDs {
+		if err := reassignIDs(&archive, &book); err != nil {
+			return err
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Unscoped().Model(&models.Book{}).Where("id = ?", book.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("book %s already exists (use -new-ids to import as a copy)", book.ID)
+		}
+
+		// 取り込み先に無いシリーズへの参照は外す
+		if book.SeriesID != nil {
+			if err := tx.Model(&models.Series{}).Where("id = ?", *book.SeriesID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				book.SeriesID = nil
+				book.SeriesOrder = 0
+			}
+		}
+
+		if err := tx.Create(&book).Error; err != nil {
+			return fmt.Errorf("failed to create book: %w", err)
+		}
+		for i := range archive.Episodes {
+			archive.Episodes[i].BookID = book.ID
+			if err := tx.Create(&archive.Episodes[i]).Error; err != nil {
+				return fmt.Errorf("failed to create episode %d: %w", archive.Episodes[i].EpisodeNo, err)
+			}
+		}
+		for i := range archive.Materials {
+			archive.Materials[i].BookID = &book.ID
+			archive.Materials[i].SeriesID = nil
+			archive.Materials[i].AuthorID = nil
+			if err := tx.Create(&archive.Materials[i]).Error; err != nil {
+				return fmt.Errorf("failed to create material %q: %w", archive.Materials[i].Title, err)
+			}
+		}
+
+		fmt.Printf("Imported book %s (%s): %d episodes, %d materials\n", book.ID, book.Title, len(archive.Episodes), len(archive.Materials))
+		return nil
+	})
+}
+
+func reassignIDs(archive *bookArchive, book *models.Book) error {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+	book.ID = id
+	for i := range archive.Episodes {
+		if archive.Episodes[i].ID, err = uuid.NewV7(); err != nil {
+			return err
+		}
+	}
+	for i := range archive.Materials {
+		if archive.Materials[i].ID, err = uuid.NewV7(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
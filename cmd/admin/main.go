@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+
+	"challecara2025-back/internal/config"
+	"challecara2025-back/internal/database"
+	"challecara2025-back/internal/logging"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// command 管理用のサブコマンド
+type command struct {
+	summary string
+	run     func(db *gorm.DB, args []string) error
+}
+
+// cfg APIと同じ方法で読み込んだ設定
+var cfg *config.Config
+
+var commands = map[string]command{
+	"seed":            {"デモ用のBook・エピソード・参考資料を作成", runSeed},
+	"export":          {"BookをJSONのアーカイブに書き出す", runExport},
+	"import":          {"JSONのアーカイブからBookを取り込む", runImport},
+	"purge":           {"削除済みのデータを完全に削除", runPurge},
+	"recompute-stats": {"いいね・お気に入り・閲覧・フォロワー数を再計算", runRecomputeStats},
+	"list-users":      {"アクティビティのある利用者を一覧表示", runListUsers},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	// APIと同じ設定ファイル・環境変数で設定を読み込み、データベースに接続
+	var err error
+	if cfg, err = config.Load(); err != nil {
+		fatal("Failed to load configuration", err)
+	}
+	// ログはAPIと同じ形式で標準エラーに出力する（標準出力はコマンドの結果に使う）
+	if _, err := logging.Setup(cfg.Log, os.Stderr); err != nil {
+		fatal("Failed to configure logging", err)
+	}
+	// SQLのログは警告以上のみ表示する
+	if err := database.Connect(cfg.Database, logging.NewGORMLogger(cfg.Log.SlowQuery).LogMode(logger.Warn)); err != nil {
+		fatal("Failed to connect to database", err)
+	}
+
+	if err := cmd.run(database.GetDB(), os.Args[2:]); err != nil {
+		slog.Error("Command failed", "command", os.Args[1], "error", err)
+		os.Exit(1)
+	}
+}
+
+// fatal エラーをログに出力して終了する
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: admin <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, commands[name].summary)
+	}
+}
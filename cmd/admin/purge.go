@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// runPurge 削除してから一定期間が過ぎたデータを関連データとファイルごと完全に削除
+func runPurge(db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	olderThan := fs.Duration("older-than", 30*24*time.Hour, "削除してからこの期間が過ぎたものを対象にする")
+	dryRun := fs.Bool("dry-run", false, "件数の表示のみ行い削除しない")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	cutoff := time.Now().Add(-*olderThan)
+	trashed := func(model interface{}) ([]uuid.UUID, error) {
+		var ids []uuid.UUID
+		err := db.Unscoped().Model(model).Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Pluck("id", &ids).Error
+		return ids, err
+	}
+
+	bookIDs, err := trashed(&models.Book{})
+	if err != nil {
+		return err
+	}
+	episodeIDs, err := trashed(&models.Episode{})
+	if err != nil {
+		return err
+	}
+	materialIDs, err := trashed(&models.Material{})
+	if err != nil {
+		return err
+	}
+	seriesIDs, err := trashed(&models.Series{})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Trashed before %s: %d books, %d episodes, %d materials, %d series\n",
+		cutoff.Format(time.RFC3339), len(bookIDs), len(episodeIDs), len(materialIDs), len(seriesIDs))
+	if *dryRun {
+		return nil
+	}
+
+	var keys []string
+	err = db.Transaction(func(tx *gorm.DB) error {
+		p := purger{tx: tx, cutoff: cutoff}
+		// 参照する側から順に削除する
+		p.run("comments", p.comments)
+		p.run("bookmarks", func() error { return p.deleteTrashed(&models.Bookmark{}) })
+		p.run("images", func() error { return p.deleteTrashedFiles(&models.BookImage{}) })
+		p.run("attachments", func() error { return p.deleteTrashedFiles(&models.Attachment{}) })
+		p.run("episodes", func() error { return p.episodes(episodeIDs) })
+		p.run("materials", func() error { return p.materials(materialIDs) })
+		p.run("books", func() error { return p.books(bookIDs) })
+		p.run("series", func() error { return p.series(seriesIDs) })
+		keys = p.keys
+		return p.err
+	})
+	if err != nil {
+		return err
+	}
+
+	// データベースから消えたファイルを削除する（失敗してもログに残すだけ）
+	for _, key := range keys {
+		if err := store.Delete(context.Background(), key); err != nil {
+			slog.Warn("Failed to delete object", "key", key, "error", err)
+		}
+	}
+	fmt.Printf("Purged. Deleted %d stored files\n", len(keys))
+	return nil
+}
+
+// purger 完全削除の途中経過（最初のエラーで以降の処理を止める）
+type purger struct {
+	tx     *gorm.DB
+	cutoff time.Time
+	keys   []string
+	err    error
+}
+
+func (p *purger) run(name string, fn func() error) {
+	if p.err != nil {
+		return
+	}
+	if err := fn(); err != nil {
+		p.err = fmt.Errorf("failed to purge %s: %w", name, err)
+	}
+}
+
+func (p *purger) deleteTrashed(model interface{}) error {
+	return p.tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", p.cutoff).Delete(model).Error
+}
+
+// deleteTrashedFiles ストレージにファイルを持つ行を削除し、ファイルのキーを記録する
+func (p *purger) deleteTrashedFiles(model interface{}) error {
+	var keys []string
+	query := p.tx.Unscoped().Model(model).Where("deleted_at IS NOT NULL AND deleted_at < ?", p.cutoff)
+	if err := query.Session(&gorm.Session{}).Pluck("storage_key", &keys).Error; err != nil {
+		return err
+	}
+	p.keys = append(p.keys, keys...)
+	return p.deleteTrashed(model)
+}
+
+// comments 返信の無い削除済みコメントを削除（返信が残っているコメントは「削除済み」として表示に使う）
+func (p *purger) comments() error {
+	var ids []uuid.UUID
+	if err := p.tx.Unscoped().Model(&models.Comment{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", p.cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM comments AS replies WHERE replies.parent_id = comments.id)").
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return p.deleteComments(p.tx.Where("id IN ?", ids))
+}
+
+// deleteComments 条件に合うコメントと通報を削除（返信を先に削除する）
+func (p *purger) deleteComments(scope *gorm.DB) error {
+	if err := p.tx.Where("comment_id IN (?)", p.tx.Unscoped().Model(&models.Comment{}).Select("id").Where(scope)).Delete(&models.CommentReport{}).Error; err != nil {
+		return err
+	}
+	if err := p.tx.Unscoped().Where(scope).Where("parent_id IS NOT NULL").Delete(&models.Comment{}).Error; err != nil {
+		return err
+	}
+	return p.tx.Unscoped().Where(scope).Delete(&models.Comment{}).Error
+}
+
+// episodes エピソードとコメント・集計値・閲覧記録を削除
+func (p *purger) episodes(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := p.deleteComments(p.tx.Where("episode_id IN ?", ids)); err != nil {
+		return err
+	}
+	for _, model := range []interface{}{&models.EpisodeStats{}, &models.EpisodeView{}, &models.EpisodeViewDaily{}} {
+		if err := p.tx.Where("episode_id IN ?", ids).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	if err := p.tx.Where("target_type = ? AND target_id IN ?", models.ReactionTargetEpisode, ids).Delete(&models.Reaction{}).Error; err != nil {
+		return err
+	}
+	return p.tx.Unscoped().Where("id IN ?", ids).Delete(&models.Episode{}).Error
+}
+
+// materials 参考資料とBookとの紐付け・添付ファイルを削除
+func (p *purger) materials(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var keys []string
+	if err := p.tx.Unscoped().Model(&models.Attachment{}).Where("material_id IN ?", ids).Pluck("storage_key", &keys).Error; err != nil {
+		return err
+	}
+	p.keys = append(p.keys, keys...)
+	if err := p.tx.Unscoped().Where("material_id IN ?", ids).Delete(&models.Attachment{}).Error; err != nil {
+		return err
+	}
+	if err := p.tx.Where("material_id IN ?", ids).Delete(&models.BookMaterial{}).Error; err != nil {
+		return err
+	}
+	return p.tx.Unscoped().Where("id IN ?", ids).Delete(&models.Material{}).Error
+}
+
+// books Bookと、そのBookに属するエピソード・参考資料・挿絵・読書データ・集計値を削除
+func (p *purger) books(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var episodeIDs, materialIDs []uuid.UUID
+	if err := p.tx.Unscoped().Model(&models.Episode{}).Where("book_id IN ?", ids).Pluck("id", &episodeIDs).Error; err != nil {
+		return err
+	}
+	if err := p.tx.Unscoped().Model(&models.Material{}).Where("book_id IN ?", ids).Pluck("id", &materialIDs).Error; err != nil {
+		return err
+	}
+	if err := p.episodes(episodeIDs); err != nil {
+		return err
+	}
+	if err := p.materials(materialIDs); err != nil {
+		return err
+	}
+
+	var books []models.Book
+	if err := p.tx.Unscoped().Select("cover_key", "thumb_key").Where("id IN ?", ids).Find(&books).Error; err != nil {
+		return err
+	}
+	for _, book := range books {
+		for _, key := range []string{book.CoverKey, book.ThumbKey} {
+			if key != "" {
+				p.keys = append(p.keys, key)
+			}
+		}
+	}
+	var imageKeys []string
+	if err := p.tx.Unscoped().Model(&models.BookImage{}).Where("book_id IN ?", ids).Pluck("storage_key", &imageKeys).Error; err != nil {
+		return err
+	}
+	p.keys = append(p.keys, imageKeys...)
+
+	for _, model := range []interface{}{&models.BookImage{}, &models.Bookmark{}} {
+		if err := p.tx.Unscoped().Where("book_id IN ?", ids).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	for _, model := range []interface{}{&models.BookMaterial{}, &models.ReadingProgress{}, &models.BookStats{}, &models.Ranking{}} {
+		if err := p.tx.Where("book_id IN ?", ids).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	if err := p.tx.Where("target_type = ? AND target_id IN ?", models.ReactionTargetBook, ids).Delete(&models.Reaction{}).Error; err != nil {
+		return err
+	}
+	return p.tx.Unscoped().Where("id IN ?", ids).Delete(&models.Book{}).Error
+}
+
+// series シリーズとシリーズ共有の参考資料を削除（所属していたBookはシリーズから外す）
+func (p *purger) series(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var materialIDs []uuid.UUID
+	if err := p.tx.Unscoped().Model(&models.Material{}).Where("series_id IN ?", ids).Pluck("id", &materialIDs).Error; err != nil {
+		return err
+	}
+	if err := p.materials(materialIDs); err != nil {
+		return err
+	}
+	if err := p.tx.Unscoped().Model(&models.Book{}).Where("series_id IN ?", ids).
+		Updates(map[string]interface{}{"series_id": nil, "series_order": 0}).Error; err != nil {
+		return err
+	}
+	return p.tx.Unscoped().Where("id IN ?", ids).Delete(&models.Series{}).Error
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// demoAuthorID -authorが未指定の場合に使うデモ用の作者ID
+var demoAuthorID = uuid.MustParse("00000000-0000-7000-8000-000000000001")
+
+var demoGenres = []string{"fantasy", "romance", "mystery", "sf"}
+
+// runSeed デモ用のBook・エピソード・参考資料を作成
+func runSeed(db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	author := fs.String("author", demoAuthorID.String(), "作者ID")
+	books := fs.Int("books", 3, "作成するBookの数")
+	episodes := fs.Int("episodes", 5, "Bookごとのエピソード数")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	authorID, err := uuid.Parse(*author)
+	if err != nil {
+		return fmt.Errorf("invalid author ID: %w", err)
+	}
+
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		// 作者ライブラリの参考資料（すべてのBookから参照できる）
+		library, err := newMaterial("世界観メモ", "大陸の地図と年表。魔法は血統によって受け継がれる。")
+		if err != nil {
+			return err
+		}
+		library.AuthorID = &authorID
+		if err := tx.Create(library).Error; err != nil {
+			return err
+		}
+
+		for i := 0; i < *books; i++ {
+			bookID, err := uuid.NewV7()
+			if err != nil {
+				return err
+			}
+			book := models.Book{
+				ID:          bookID,
+				Title:       fmt.Sprintf("デモ作品 %d", i+1),
+				Description: "シードコマンドで作成したデモ用の作品です。",
+				AuthorID:    authorID,
+				Genre:       demoGenres[i%len(demoGenres)],
+				Status:      models.BookStatusDraft,
+			}
+			if err := book.TransitionStatus(models.BookStatusPublished, now); err != nil {
+				return err
+			}
+			if err := tx.Create(&book).Error; err != nil {
+				return err
+			}
+
+			for n := 1; n <= *episodes; n++ {
+				episodeID, err := uuid.NewV7()
+				if err != nil {
+					return err
+				}
+				episode := models.Episode{
+					ID:        episodeID,
+					BookID:    book.ID,
+					Title:     fmt.Sprintf("第%d話", n),
+					Content:   fmt.Sprintf("　%sの第%d話の本文です。\n　物語はここから動き出す。", book.Title, n),
+					EpisodeNo: n,
+				}
+				// 最後の1話は下書きのまま残す
+				status := models.EpisodeStatusPublished
+				if n == *episodes && n > 1 {
+					status = models.EpisodeStatusDraft
+				}
+				if err := episode.ApplyStatus(status, nil, now); err != nil {
+					return err
+				}
+				if err := tx.Create(&episode).Error; err != nil {
+					return err
+				}
+			}
+
+			material, err := newMaterial("登場人物", book.Title+"の主人公とライバルの設定。")
+			if err != nil {
+				return err
+			}
+			material.BookID = &book.ID
+			if err := tx.Create(material).Error; err != nil {
+				return err
+			}
+
+			fmt.Printf("Created book %s (%s)\n", book.ID, book.Title)
+		}
+		return nil
+	})
+}
+
+func newMaterial(title, content string) (*models.Material, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	return &models.Material{ID: id, Title: title, Content: content}, nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// reactionCount 対象・種類ごとのリアクション数
+type reactionCount struct {
+	TargetID uuid.UUID
+	Kind     string
+	Total    int64
+}
+
+// runRecomputeStats カウンタテーブルを元データ（リアクション・閲覧記録・フォロー）から作り直す
+func runRecomputeStats(db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("recompute-stats", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		bookStats, err := recomputeBookStats(tx, now)
+		if err != nil {
+			return err
+		}
+		episodeStats, err := recomputeEpisodeStats(tx, now)
+		if err != nil {
+			return err
+		}
+		authorStats, err := recomputeAuthorStats(tx, now)
+		if err != nil {
+			return err
+		}
+
+		all := tx.Session(&gorm.Session{AllowGlobalUpdate: true})
+		for _, model := range []interface{}{&models.BookStats{}, &models.EpisodeStats{}, &models.AuthorStats{}} {
+			if err := all.Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if len(bookStats) > 0 {
+			if err := tx.CreateInBatches(bookStats, 100).Error; err != nil {
+				return err
+			}
+		}
+		if len(episodeStats) > 0 {
+			if err := tx.CreateInBatches(episodeStats, 100).Error; err != nil {
+				return err
+			}
+		}
+		if len(authorStats) > 0 {
+			if err := tx.CreateInBatches(authorStats, 100).Error; err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("Recomputed stats for %d books, %d episodes, %d authors\n", len(bookStats), len(episodeStats), len(authorStats))
+		return nil
+	})
+}
+
+func countReactions(tx *gorm.DB, targetType string) ([]reactionCount, error) {
+	var counts []reactionCount
+	err := tx.Model(&models.Reaction{}).
+		Select("target_id, kind, COUNT(*) AS total").
+		Where("target_type = ?", targetType).
+		Group("target_id, kind").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func recomputeBookStats(tx *gorm.DB, now time.Time) ([]models.BookStats, error) {
+	// 削除済みのBookにも行は残せるが、存在しないBookの行は作らない
+	var bookIDs []uuid.UUID
+	if err := tx.Unscoped().Model(&models.Book{}).Pluck("id", &bookIDs).Error; err != nil {
+		return nil, err
+	}
+	stats := make(map[uuid.UUID]*models.BookStats, len(bookIDs))
+	for _, id := range bookIDs {
+		stats[id] = &models.BookStats{BookID: id, UpdatedAt: now}
+	}
+
+	reactions, err := countReactions(tx, models.ReactionTargetBook)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reactions {
+		s, ok := stats[r.TargetID]
+		if !ok {
+			continue
+		}
+		switch r.Kind {
+		case models.ReactionLike:
+			s.LikeCount = r.Total
+		case models.ReactionFavorite:
+			s.FavoriteCount = r.Total
+		}
+	}
+
+	var views []struct {
+		BookID uuid.UUID
+		Total  int64
+	}
+	if err := tx.Model(&models.EpisodeViewDaily{}).Select("book_id, SUM(views) AS total").Group("book_id").Scan(&views).Error; err != nil {
+		return nil, err
+	}
+	for _, v := range views {
+		if s, ok := stats[v.BookID]; ok {
+			s.ViewCount = v.Total
+		}
+	}
+
+	result := make([]models.BookStats, 0, len(stats))
+	for _, id := range bookIDs {
+		if s := stats[id]; s.LikeCount > 0 || s.FavoriteCount > 0 || s.ViewCount > 0 {
+			result = append(result, *s)
+		}
+	}
+	return result, nil
+}
+
+func recomputeEpisodeStats(tx *gorm.DB, now time.Time) ([]models.EpisodeStats, error) {
+	reactions, err := countReactions(tx, models.ReactionTargetEpisode)
+	if err != nil {
+		return nil, err
+	}
+
+	var episodes []models.Episode
+	if err := tx.Unscoped().Select("id", "book_id").Find(&episodes).Error; err != nil {
+		return nil, err
+	}
+	bookOf := make(map[uuid.UUID]uuid.UUID, len(episodes))
+	for _, episode := range episodes {
+		bookOf[episode.ID] = episode.BookID
+	}
+
+	stats := make(map[uuid.UUID]*models.EpisodeStats)
+	var order []uuid.UUID
+	for _, r := range reactions {
+		bookID, ok := bookOf[r.TargetID]
+		if !ok {
+			continue
+		}
+		s, ok := stats[r.TargetID]
+		if !ok {
+			s = &models.EpisodeStats{EpisodeID: r.TargetID, BookID: bookID, UpdatedAt: now}
+			stats[r.TargetID] = s
+			order = append(order, r.TargetID)
+		}
+		switch r.Kind {
+		case models.ReactionLike:
+			s.LikeCount = r.Total
+		case models.ReactionFavorite:
+			s.FavoriteCount = r.Total
+		}
+	}
+
+	result := make([]models.EpisodeStats, 0, len(order))
+	for _, id := range order {
+		result = append(result, *stats[id])
+	}
+	return result, nil
+}
+
+func recomputeAuthorStats(tx *gorm.DB, now time.Time) ([]models.AuthorStats, error) {
+	var followers []struct {
+		AuthorID uuid.UUID
+		Total    int64
+	}
+	if err := tx.Model(&models.Follow{}).Select("author_id, COUNT(*) AS total").Group("author_id").Scan(&followers).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]models.AuthorStats, 0, len(followers))
+	for _, f := range followers {
+		result = append(result, models.AuthorStats{AuthorID: f.AuthorID, FollowerCount: f.Total, UpdatedAt: now})
+	}
+	return result, nil
+}
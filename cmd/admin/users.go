@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// userSummary 利用者ごとのアクティビティ（利用者テーブルは無いため各テーブルのIDから集める）
+type userSummary struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Books     int64     `json:"books"`
+	Comments  int64     `json:"comments"`
+	Reactions int64     `json:"reactions"`
+	Bookmarks int64     `json:"bookmarks"`
+	Reading   int64     `json:"reading"`
+	Following int64     `json:"following"`
+	Followers int64     `json:"followers"`
+}
+
+// runListUsers 作者・読者としてアクティビティのある利用者を一覧表示
+func runListUsers(db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("list-users", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "JSONで出力する")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	users := make(map[uuid.UUID]*userSummary)
+	count := func(model interface{}, column string, field func(*userSummary) *int64) error {
+		var rows []struct {
+			ID    uuid.UUID
+			Total int64
+		}
+		if err := db.Model(model).Select(column + " AS id, COUNT(*) AS total").Group(column).Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if row.ID == uuid.Nil {
+				continue
+			}
+			user, ok := users[row.ID]
+			if !ok {
+				user = &userSummary{UserID: row.ID}
+				users[row.ID] = user
+			}
+			*field(user) = row.Total
+		}
+		return nil
+	}
+
+	counters := []struct {
+		model  interface{}
+		column string
+		field  func(*userSummary) *int64
+	}{
+		{&models.Book{}, "author_id", func(u *userSummary) *int64 { return &u.Books }},
+		{&models.Comment{}, "user_id", func(u *userSummary) *int64 { return &u.Comments }},
+		{&models.Reaction{}, "user_id", func(u *userSummary) *int64 { return &u.Reactions }},
+		{&models.Bookmark{}, "user_id", func(u *userSummary) *int64 { return &u.Bookmarks }},
+		{&models.ReadingProgress{}, "user_id", func(u *userSummary) *int64 { return &u.Reading }},
+		{&models.Follow{}, "follower_id", func(u *userSummary) *int64 { return &u.Following }},
+		{&models.Follow{}, "author_id", func(u *userSummary) *int64 { return &u.Followers }},
+	}
+	for _, counter := range counters {
+		if err := count(counter.model, counter.column, counter.field); err != nil {
+			return err
+		}
+	}
+
+	summaries := make([]userSummary, 0, len(users))
+	for _, user := range users {
+		summaries = append(summaries, *user)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].UserID.String() < summaries[j].UserID.String() })
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summaries)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER_ID\tBOOKS\tCOMMENTS\tREACTIONS\tBOOKMARKS\tREADING\tFOLLOWING\tFOLLOWERS")
+	for _, u := range summaries {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n", u.UserID, u.Books, u.Comments, u.Reactions, u.Bookmarks, u.Reading, u.Following, u.Followers)
+	}
+	return w.Flush()
+}
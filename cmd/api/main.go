@@ -1,36 +1,107 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"challecara2025-back/internal/config"
 	"challecara2025-back/internal/database"
 	"challecara2025-back/internal/handlers"
-	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/logging"
+	"challecara2025-back/internal/metrics"
+	"challecara2025-back/internal/migrations"
+	"challecara2025-back/internal/repository"
+	"challecara2025-back/internal/scheduler"
+	"challecara2025-back/internal/storage"
+	"challecara2025-back/internal/telemetry"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// 設定を読み込む（既定値 → CONFIG_FILE → 環境変数）
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("Failed to load configuration", err)
+	}
+
+	// 構造化ログ（JSON）を標準のロガーに設定
+	if _, err := logging.Setup(cfg.Log, os.Stdout); err != nil {
+		fatal("Failed to configure logging", err)
+	}
+	slog.Info("Effective configuration", "config", cfg)
+
 	// データベースに接続
-	if err := database.Connect(); err != nil {
-		log.Fatal("Failed to connect to database:", err)
+	if err := database.Connect(cfg.Database, logging.NewGORMLogger(cfg.Log.SlowQuery)); err != nil {
+		fatal("Failed to connect to database", err)
 	}
 
-	// マイグレーション実行
-	if err := database.Migrate(&models.Book{}, &models.Episode{}, &models.Material{}); err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	// サブコマンド（api migrate up|down|status）
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fatal("Migration failed", err)
+		}
+		return
 	}
 
-	// Ginルーターを初期化
-	router := gin.Default()
+	// 未適用のマイグレーションを適用（AUTO_MIGRATE=false の場合は api migrate up で別途実行する）
+	if cfg.Server.AutoMigrate {
+		if _, err := migrations.New(database.GetDB()).Up(context.Background()); err != nil {
+			fatal("Failed to migrate database", err)
+		}
+	}
+
+	// アップロードファイルの保存先を初期化
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		fatal("Failed to initialize storage", err)
+	}
+
+	// SIGINT・SIGTERMを受け取ったら停止処理に入る
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// GORMのクエリと接続プールを計測する
+	if err := metrics.RegisterDB(database.GetDB()); err != nil {
+		fatal("Failed to register database metrics", err)
+	}
+
+	// OpenTelemetryのトレース（リクエストごとのスパンと、その子スパンとしてのクエリ）
+	tracerProvider, err := telemetry.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Failed to configure tracing", err)
+	}
+	if err := telemetry.InstrumentDB(database.GetDB(), tracerProvider); err != nil {
+		fatal("Failed to instrument database", err)
+	}
+
+	// バックグラウンドジョブを開始（サーバーとは別に、停止時に個別に止める）。
+	// ジョブはすぐにクエリを実行するため、GORMのプラグインをすべて登録してから開始する
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+	jobs := scheduler.New()
+	jobs.Every("publish-scheduled-episodes", cfg.Jobs.PublishInterval, scheduler.PublishDueEpisodes(database.GetDB()))
+	jobs.Every("compute-rankings", cfg.Jobs.RankingInterval, scheduler.ComputeRankings(database.GetDB()))
+	jobs.Start(jobsCtx)
+
+	// Ginルーターを初期化（アクセスログはリクエストIDとともにslogで出力する）
+	router := gin.New()
+	router.Use(gin.Recovery(), logging.RequestIDMiddleware())
+	router.Use(telemetry.Middleware(cfg.Tracing.ServiceName, tracerProvider)...)
+	router.Use(logging.AccessLog(), metrics.Middleware())
 
 	// CORSミドルウェアを追加
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-User-ID, X-Session-ID, X-Request-ID, traceparent, tracestate")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, X-Trace-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -42,9 +113,24 @@ func main() {
 
 	// ハンドラーを初期化
 	db := database.GetDB()
-	bookHandler := handlers.NewBookHandler(db)
-	episodeHandler := handlers.NewEpisodeHandler(db)
-	materialHandler := handlers.NewMaterialHandler(db)
+	bookRepo := repository.NewBookRepository(db)
+	episodeRepo := repository.NewEpisodeRepository(db)
+	materialRepo := repository.NewMaterialRepository(db)
+	bookHandler := handlers.NewBookHandler(bookRepo)
+	episodeHandler := handlers.NewEpisodeHandler(episodeRepo, bookRepo)
+	materialHandler := handlers.NewMaterialHandler(materialRepo, bookRepo)
+	seriesHandler := handlers.NewSeriesHandler(db)
+	publicHandler := handlers.NewPublicHandler(db, bookRepo)
+	readerHandler := handlers.NewReaderHandler(db)
+	commentHandler := handlers.NewCommentHandler(db)
+	engagementHandler := handlers.NewEngagementHandler(db, bookRepo)
+	rankingHandler := handlers.NewRankingHandler(db)
+	analyticsHandler := handlers.NewAnalyticsHandler(db)
+	coverHandler := handlers.NewCoverHandler(db, store)
+	attachmentHandler := handlers.NewAttachmentHandler(db, store, cfg.Uploads.AttachmentQuota)
+	imageHandler := handlers.NewImageHandler(db, store)
+	exportHandler := handlers.NewExportHandler(db, store)
+	healthHandler := handlers.NewHealthHandler(db)
 
 	// APIルートを設定
 	api := router.Group("/api")
@@ -56,8 +142,16 @@ func main() {
 			books.GET("", bookHandler.GetBooks)
 			books.GET("/:id", bookHandler.GetBook)
 			books.PUT("/:id", bookHandler.UpdateBook)
+			books.PUT("/:id/status", bookHandler.UpdateBookStatus)
+			books.POST("/:id/cover", coverHandler.UploadCover)
+			books.DELETE("/:id/cover", coverHandler.DeleteCover)
 			books.DELETE("/:id", bookHandler.DeleteBook)
 
+			// 挿絵・EPUB書き出し
+			books.POST("/:id/images", imageHandler.UploadImage)
+			books.GET("/:id/images", imageHandler.GetImages)
+			books.GET("/:id/epub", exportHandler.ExportEPUB)
+
 			// エピソード関連のルート（資料配下）- パラメータ名を :id に統一
 			books.POST("/:id/episodes", episodeHandler.CreateEpisode)
 			books.GET("/:id/episodes", episodeHandler.GetEpisodes)
@@ -67,6 +161,20 @@ func main() {
 			books.POST("/:id/materials", materialHandler.CreateMaterial)
 			books.GET("/:id/materials", materialHandler.GetMaterials)
 			books.POST("/:id/materials/batch", materialHandler.GetMaterialsByIDs)
+			books.POST("/:id/materials/attach", materialHandler.AttachMaterial)
+			books.DELETE("/:id/materials/:material_id", materialHandler.DetachMaterial)
+
+			// いいね・お気に入り
+			books.POST("/:id/like", engagementHandler.LikeBook)
+			books.DELETE("/:id/like", engagementHandler.UnlikeBook)
+			books.POST("/:id/favorite", engagementHandler.FavoriteBook)
+			books.DELETE("/:id/favorite", engagementHandler.UnfavoriteBook)
+
+			// 作者向けの閲覧分析
+			books.GET("/:id/analytics", analyticsHandler.GetBookAnalytics)
+
+			// 作者向けのコメントモデレーション
+			books.GET("/:id/comments/reported", commentHandler.GetReportedComments)
 		}
 
 		// エピソード関連のルート（直接アクセス）
@@ -75,6 +183,25 @@ func main() {
 			episodes.GET("/:id", episodeHandler.GetEpisode)
 			episodes.PUT("/:id", episodeHandler.UpdateEpisode)
 			episodes.DELETE("/:id", episodeHandler.DeleteEpisode)
+			episodes.GET("/:id/html", exportHandler.GetEpisodeHTML)
+
+			// コメント関連のルート（エピソード配下）
+			episodes.GET("/:id/comments", commentHandler.GetComments)
+			episodes.POST("/:id/comments", commentHandler.CreateComment)
+
+			// いいね・お気に入り
+			episodes.POST("/:id/like", engagementHandler.LikeEpisode)
+			episodes.DELETE("/:id/like", engagementHandler.UnlikeEpisode)
+			episodes.POST("/:id/favorite", engagementHandler.FavoriteEpisode)
+			episodes.DELETE("/:id/favorite", engagementHandler.UnfavoriteEpisode)
+		}
+
+		// コメント関連のルート（直接アクセス）
+		comments := api.Group("/comments")
+		{
+			comments.DELETE("/:id", commentHandler.DeleteComment)
+			comments.POST("/:id/report", commentHandler.ReportComment)
+			comments.PUT("/:id/visibility", commentHandler.UpdateCommentVisibility)
 		}
 
 		// 参考資料関連のルート（直接アクセス）
@@ -83,24 +210,148 @@ func main() {
 			materials.GET("/:id", materialHandler.GetMaterial)
 			materials.PUT("/:id", materialHandler.UpdateMaterial)
 			materials.DELETE("/:id", materialHandler.DeleteMaterial)
+
+			// 添付ファイル
+			materials.POST("/:id/attachments", attachmentHandler.UploadAttachment)
+			materials.GET("/:id/attachments", attachmentHandler.GetAttachments)
+		}
+
+		// 挿絵関連のルート（直接アクセス）
+		images := api.Group("/images")
+		{
+			images.DELETE("/:id", imageHandler.DeleteImage)
+		}
+
+		// 添付ファイル関連のルート（直接アクセス）
+		attachments := api.Group("/attachments")
+		{
+			attachments.GET("/:id/download", attachmentHandler.DownloadAttachment)
+			attachments.DELETE("/:id", attachmentHandler.DeleteAttachment)
+		}
+
+		// 作者関連のルート（作者ライブラリの参考資料・フォロー）
+		authors := api.Group("/authors")
+		{
+			authors.POST("/:id/materials", materialHandler.CreateLibraryMaterial)
+			authors.GET("/:id/materials", materialHandler.GetLibraryMaterials)
+
+			// フォロー
+			authors.GET("/:id/stats", engagementHandler.GetAuthorStats)
+			authors.GET("/:id/storage", attachmentHandler.GetAuthorUsage)
+			authors.POST("/:id/follow", engagementHandler.FollowAuthor)
+			authors.DELETE("/:id/follow", engagementHandler.UnfollowAuthor)
+		}
+
+		// シリーズ関連のルート
+		series := api.Group("/series")
+		{
+			series.POST("", seriesHandler.CreateSeries)
+			series.GET("", seriesHandler.GetSeriesList)
+			series.GET("/:id", seriesHandler.GetSeries)
+			series.PUT("/:id", seriesHandler.UpdateSeries)
+			series.DELETE("/:id", seriesHandler.DeleteSeries)
+
+			// 巻の追加・並べ替え・削除
+			series.POST("/:id/books", seriesHandler.AddBook)
+			series.PUT("/:id/books/order", seriesHandler.ReorderBooks)
+			series.DELETE("/:id/books/:book_id", seriesHandler.RemoveBook)
+
+			// シリーズ共有の参考資料
+			series.POST("/:id/materials", seriesHandler.CreateSeriesMaterial)
+			series.GET("/:id/materials", seriesHandler.GetSeriesMaterials)
+		}
+
+		// 読者向けの公開API（公開済みのBook・エピソードのみ、参考資料は含まない）
+		public := api.Group("/public")
+		{
+			public.GET("/books", publicHandler.GetBooks)
+			public.GET("/books/:id", publicHandler.GetBook)
+			public.GET("/books/:id/episodes", publicHandler.GetTableOfContents)
+			public.GET("/episodes/:id", publicHandler.GetEpisode)
+		}
+
+		// ランキング（定期ジョブで集計済みのものを返す）
+		api.GET("/rankings", rankingHandler.GetRankings)
+
+		// 読者自身のしおり・読書位置・お気に入り（X-User-ID ヘッダーで利用者を識別）
+		me := api.Group("/me")
+		{
+			me.GET("/bookmarks", readerHandler.GetBookmarks)
+			me.POST("/bookmarks", readerHandler.CreateBookmark)
+			me.DELETE("/bookmarks/:id", readerHandler.DeleteBookmark)
+
+			me.GET("/progress/:book_id", readerHandler.GetProgress)
+			me.PUT("/progress/:book_id", readerHandler.SaveProgress)
+			me.GET("/continue-reading", readerHandler.GetContinueReading)
+
+			me.GET("/favorites", engagementHandler.GetFavorites)
+			me.GET("/following", engagementHandler.GetFollowing)
 		}
 	}
 
-	// ヘルスチェック用エンドポイント
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ok",
-		})
-	})
+	// ローカルストレージの場合は表紙・挿絵を配信する（添付ファイルはダウンロードAPIからのみ）
+	if local, ok := store.(*storage.LocalStorage); ok {
+		files := gin.WrapH(http.StripPrefix(storage.LocalURLPrefix, local.PublicHandler()))
+		router.GET(storage.LocalURLPrefix+"/*key", files)
+		router.HEAD(storage.LocalURLPrefix+"/*key", files)
+	}
+
+	// ヘルスチェック用エンドポイント（/health は互換性のため /livez と同じ）
+	router.GET("/livez", healthHandler.Livez)
+	router.GET("/readyz", healthHandler.Readyz)
+	router.GET("/health", healthHandler.Livez)
+
+	// Prometheus形式のメトリクス
+	router.GET("/metrics", metrics.Handler())
 
 	// サーバーを起動
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	server := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           router,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server starting", "port", cfg.Server.Port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fatal("Failed to start server", err)
+		}
+	case <-ctx.Done():
+		stop()
+		slog.Info("Shutting down, waiting for in-flight requests", "timeout", cfg.Server.ShutdownTimeout.String())
+	}
+
+	// 処理中のリクエストを待ってから、ジョブ・データベースの順に停止する
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Server shutdown did not complete", "error", err)
+	}
+
+	cancelJobs()
+	jobs.Wait()
+
+	// 未送信のスパンを送信してから終了する
+	if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
 	}
 
-	log.Printf("Server starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	if err := database.Close(); err != nil {
+		slog.Error("Failed to close database", "error", err)
 	}
+	slog.Info("Server stopped")
+}
+
+// fatal エラーを出力して終了する
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,417 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Config APIと管理用コマンドの設定
+//
+// 既定値 → 設定ファイル（CONFIG_FILE、YAML）→ 環境変数 の順に上書きする。
+// 各フィールドの env タグが対応する環境変数、secret タグの付いた値はログに出さない。
+type Config struct {
+	Server   Server   `yaml:"server"`
+	Database Database `yaml:"database"`
+	Storage  Storage  `yaml:"storage"`
+	Jobs     Jobs     `yaml:"jobs"`
+	Uploads  Uploads  `yaml:"uploads"`
+	Log      Log      `yaml:"log"`
+	Tracing  Tracing  `yaml:"tracing"`
+}
+
+// Server HTTPサーバーの設定
+type Server struct {
+	Port string `yaml:"port" env:"PORT"`
+	// AutoMigrate 起動時に未適用のマイグレーションを適用するか
+	AutoMigrate bool `yaml:"auto_migrate" env:"AUTO_MIGRATE"`
+
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	// ShutdownTimeout 停止時に処理中のリクエストの完了を待つ時間
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+}
+
+// Database データベースの接続設定
+type Database struct {
+	Driver   string `yaml:"driver" env:"DB_DRIVER"`
+	Host     string `yaml:"host" env:"DB_HOST"`
+	Port     string `yaml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `yaml:"name" env:"DB_NAME"`
+	SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE"`
+	// Path SQLiteのデータベースファイル（":memory:"の場合はメモリ上）
+	Path string `yaml:"path" env:"DB_PATH"`
+
+	// 接続プールの設定（SQLiteは常に1接続で、接続の寿命の設定は使わない）
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	// ConnectTimeout 起動時に接続できるまで再試行する時間（0の場合は再試行しない）
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+}
+
+// Storage アップロードファイルの保存先の設定
+type Storage struct {
+	Driver    string `yaml:"driver" env:"STORAGE_DRIVER"`
+	LocalDir  string `yaml:"local_dir" env:"STORAGE_LOCAL_DIR"`
+	PublicURL string `yaml:"public_url" env:"STORAGE_PUBLIC_URL"`
+	S3        S3     `yaml:"s3"`
+}
+
+// S3 S3互換ストレージの接続設定
+type S3 struct {
+	Endpoint        string `yaml:"endpoint" env:"S3_ENDPOINT"`
+	Bucket          string `yaml:"bucket" env:"S3_BUCKET"`
+	Region          string `yaml:"region" env:"S3_REGION"`
+	AccessKeyID     string `yaml:"access_key_id" env:"S3_ACCESS_KEY_ID"`
+	SecretAccessKey string `yaml:"secret_access_key" env:"S3_SECRET_ACCESS_KEY" secret:"true"`
+	UseSSL          bool   `yaml:"use_ssl" env:"S3_USE_SSL"`
+}
+
+// Jobs バックグラウンドジョブの実行間隔
+type Jobs struct {
+	PublishInterval time.Duration `yaml:"publish_interval" env:"JOBS_PUBLISH_INTERVAL"`
+	RankingInterval time.Duration `yaml:"ranking_interval" env:"JOBS_RANKING_INTERVAL"`
+}
+
+// Uploads アップロードの上限
+type Uploads struct {
+	// AttachmentQuota 作者ごとの添付ファイルの合計サイズの上限（バイト）
+	AttachmentQuota int64 `yaml:"attachment_quota" env:"ATTACHMENT_QUOTA"`
+}
+
+// Log ログの出力設定
+type Log struct {
+	// Level debug・info・warn・error（debugではSQLも出力する）
+	Level string `yaml:"level" env:"LOG_LEVEL"`
+	// Format json または text
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+	// SlowQuery これより時間のかかったクエリを警告として出力する
+	SlowQuery time.Duration `yaml:"slow_query" env:"LOG_SLOW_QUERY"`
+}
+
+// Tracing OpenTelemetryのトレースの設定
+type Tracing struct {
+	// Exporter none（トレースIDの発行のみで送信しない）または otlp（OTLP/HTTPで送信）
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER"`
+	// OTLPEndpoint 送信先のURL（例: http://localhost:4318/v1/traces）。空の場合は OTEL_EXPORTER_OTLP_* に従う
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	ServiceName  string  `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+	SampleRatio  float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// データベースのドライバー
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// ストレージのドライバー
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// redacted ログに出すときにシークレットの代わりに表示する文字列
+const redacted = "[REDACTED]"
+
+// Default 既定値の設定を返す
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Port:              "8080",
+			AutoMigrate:       true,
+			ReadTimeout:       30 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Database: Database{
+			Driver:  DriverMySQL,
+			SSLMode: "disable",
+			Path:    "challecara.db",
+
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectTimeout:  time.Minute,
+		},
+		Storage: Storage{
+			Driver:   StorageLocal,
+			LocalDir: "./uploads",
+			S3:       S3{UseSSL: true},
+		},
+		Jobs: Jobs{
+			PublishInterval: time.Minute,
+			RankingInterval: 10 * time.Minute,
+		},
+		Uploads: Uploads{
+			AttachmentQuota: 100 << 20,
+		},
+		Log: Log{
+			Level:     "info",
+			Format:    "json",
+			SlowQuery: 200 * time.Millisecond,
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "challecara-api",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// Load 既定値・設定ファイル（環境変数 CONFIG_FILE）・環境変数から設定を読み込み検証する
+func Load() (*Config, error) {
+	cfg := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+	cfg.Database.applyDriverDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile YAMLの設定ファイルを読み込む（未知のキーはエラー）
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.UnmarshalWithOptions(data, c, yaml.DisallowUnknownField()); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyDriverDefaults ドライバーごとに異なる既定のポートを補う
+func (d *Database) applyDriverDefaults() {
+	if d.Port != "" {
+		return
+	}
+	switch d.Driver {
+	case DriverMySQL:
+		d.Port = "3306"
+	case DriverPostgres:
+		d.Port = "5432"
+	}
+}
+
+// Validate 設定値を検証し、問題をまとめて返す
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port <= 0 || port > 65535 {
+		add("PORT must be a port number, got %q", c.Server.Port)
+	}
+
+	positive := func(name string, d time.Duration) {
+		if d <= 0 {
+			add("%s must be positive, got %s", name, d)
+		}
+	}
+	positive("SERVER_READ_TIMEOUT", c.Server.ReadTimeout)
+	positive("SERVER_READ_HEADER_TIMEOUT", c.Server.ReadHeaderTimeout)
+	positive("SERVER_WRITE_TIMEOUT", c.Server.WriteTimeout)
+	positive("SERVER_IDLE_TIMEOUT", c.Server.IdleTimeout)
+	positive("SERVER_SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout)
+
+	switch c.Database.Driver {
+	case DriverMySQL, DriverPostgres:
+		if c.Database.Host == "" {
+			add("DB_HOST is required for DB_DRIVER=%s", c.Database.Driver)
+		}
+		if c.Database.User == "" {
+			add("DB_USER is required for DB_DRIVER=%s", c.Database.Driver)
+		}
+		if c.Database.Name == "" {
+			add("DB_NAME is required for DB_DRIVER=%s", c.Database.Driver)
+		}
+		if _, err := strconv.Atoi(c.Database.Port); err != nil {
+			add("DB_PORT must be a number, got %q", c.Database.Port)
+		}
+	case DriverSQLite:
+		if c.Database.Path == "" {
+			add("DB_PATH is required for DB_DRIVER=sqlite")
+		}
+	default:
+		add("DB_DRIVER must be %s, %s or %s, got %q", DriverMySQL, DriverPostgres, DriverSQLite, c.Database.Driver)
+	}
+
+	if c.Database.MaxOpenConns <= 0 {
+		add("DB_MAX_OPEN_CONNS must be positive, got %d", c.Database.MaxOpenConns)
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		add("DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS, got %d", c.Database.MaxIdleConns)
+	}
+	notNegative := func(name string, d time.Duration) {
+		if d < 0 {
+			add("%s must not be negative, got %s", name, d)
+		}
+	}
+	notNegative("DB_CONN_MAX_LIFETIME", c.Database.ConnMaxLifetime)
+	notNegative("DB_CONN_MAX_IDLE_TIME", c.Database.ConnMaxIdleTime)
+	notNegative("DB_CONNECT_TIMEOUT", c.Database.ConnectTimeout)
+
+	switch c.Storage.Driver {
+	case StorageLocal:
+		if c.Storage.LocalDir == "" {
+			add("STORAGE_LOCAL_DIR is required for STORAGE_DRIVER=local")
+		}
+	case StorageS3:
+		if c.Storage.S3.Endpoint == "" {
+			add("S3_ENDPOINT is required for STORAGE_DRIVER=s3")
+		}
+		if c.Storage.S3.Bucket == "" {
+			add("S3_BUCKET is required for STORAGE_DRIVER=s3")
+		}
+	default:
+		add("STORAGE_DRIVER must be %s or %s, got %q", StorageLocal, StorageS3, c.Storage.Driver)
+	}
+
+	positive("JOBS_PUBLISH_INTERVAL", c.Jobs.PublishInterval)
+	positive("JOBS_RANKING_INTERVAL", c.Jobs.RankingInterval)
+	if c.Uploads.AttachmentQuota <= 0 {
+		add("ATTACHMENT_QUOTA must be positive, got %d", c.Uploads.AttachmentQuota)
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		add("LOG_LEVEL must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		add("LOG_FORMAT must be json or text, got %q", c.Log.Format)
+	}
+	positive("LOG_SLOW_QUERY", c.Log.SlowQuery)
+
+	if c.Tracing.Exporter != "none" && c.Tracing.Exporter != "otlp" {
+		add("TRACING_EXPORTER must be none or otlp, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.ServiceName == "" {
+		add("OTEL_SERVICE_NAME is required")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("TRACING_SAMPLE_RATIO must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// LogValue シークレットを伏せた設定を「database.host」のようなキーで返す（起動時のログ用）
+func (c *Config) LogValue() slog.Value {
+	var attrs []slog.Attr
+	walk(reflect.ValueOf(c).Elem(), "", func(key string, field reflect.StructField, v reflect.Value) {
+		value := fmt.Sprint(v.Interface())
+		if field.Tag.Get("secret") == "true" && value != "" {
+			value = redacted
+		}
+		attrs = append(attrs, slog.String(key, value))
+	})
+	return slog.GroupValue(attrs...)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv env タグの環境変数が設定されていれば値を上書きする
+func applyEnv(v reflect.Value) error {
+	var errs []error
+	walk(v, "", func(_ string, field reflect.StructField, v reflect.Value) {
+		name := field.Tag.Get("env")
+		if name == "" {
+			return
+		}
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+		if err := setValue(v, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// setValue 文字列をフィールドの型に変換して設定する
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported config type %s", v.Type())
+	}
+	return nil
+}
+
+// walk 構造体の葉のフィールドを yaml タグのキー（例: database.host）とともに順に訪れる
+func walk(v reflect.Value, prefix string, visit func(key string, field reflect.StructField, v reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if field.Type.Kind() == reflect.Struct {
+			walk(v.Field(i), key, visit)
+			continue
+		}
+		visit(key, field, v.Field(i))
+	}
+}
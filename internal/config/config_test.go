@@ -0,0 +1,229 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv 設定に使う環境変数をテストの間だけ未設定にする
+func clearEnv(t *testing.T) {
+	t.Helper()
+	names := []string{"CONFIG_FILE"}
+	walk(reflect.ValueOf(Default()).Elem(), "", func(_ string, field reflect.StructField, _ reflect.Value) {
+		if name := field.Tag.Get("env"); name != "" {
+			names = append(names, name)
+		}
+	})
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func TestLoadFromEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DB_DRIVER", "postgres")
+	t.Setenv("DB_HOST", "db")
+	t.Setenv("DB_USER", "app")
+	t.Setenv("DB_NAME", "challecara")
+	t.Setenv("AUTO_MIGRATE", "false")
+	t.Setenv("S3_USE_SSL", "0")
+	t.Setenv("SERVER_READ_TIMEOUT", "1m30s")
+	t.Setenv("DB_MAX_OPEN_CONNS", "50")
+	t.Setenv("ATTACHMENT_QUOTA", "1048576")
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.AutoMigrate || cfg.Storage.S3.UseSSL {
+		t.Errorf("auto_migrate = %v, use_ssl = %v, want false", cfg.Server.AutoMigrate, cfg.Storage.S3.UseSSL)
+	}
+	if cfg.Server.ReadTimeout != 90*time.Second {
+		t.Errorf("read_timeout = %s, want 1m30s", cfg.Server.ReadTimeout)
+	}
+	if cfg.Database.MaxOpenConns != 50 || cfg.Uploads.AttachmentQuota != 1<<20 {
+		t.Errorf("max_open_conns = %d, attachment_quota = %d, want 50, %d", cfg.Database.MaxOpenConns, cfg.Uploads.AttachmentQuota, 1<<20)
+	}
+	if cfg.Tracing.SampleRatio != 0.25 {
+		t.Errorf("sample_ratio = %g, want 0.25", cfg.Tracing.SampleRatio)
+	}
+	// ポートを指定しない場合はドライバーの既定値になる
+	if cfg.Database.Port != "5432" {
+		t.Errorf("db port = %q, want 5432", cfg.Database.Port)
+	}
+	// 設定していない値は既定値のまま
+	if cfg.Server.WriteTimeout != Default().Server.WriteTimeout {
+		t.Errorf("write_timeout = %s, want the default", cfg.Server.WriteTimeout)
+	}
+}
+
+func TestLoadRejectsMalformedEnv(t *testing.T) {
+	tests := []struct {
+		name, value string
+	}{
+		{"SERVER_READ_TIMEOUT", "30"},
+		{"AUTO_MIGRATE", "yes please"},
+		{"DB_MAX_OPEN_CONNS", "many"},
+		{"ATTACHMENT_QUOTA", "1.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("DB_DRIVER", "sqlite")
+			t.Setenv(tt.name, tt.value)
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.name) {
+				t.Errorf("Load error = %v, want one naming %s", err, tt.name)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		return path
+	}
+
+	clearEnv(t)
+	t.Setenv("CONFIG_FILE", write("config.yaml", `
+database:
+  driver: sqlite
+  path: /tmp/test.db
+log:
+  level: debug
+  slow_query: 1s
+`))
+	// 環境変数は設定ファイルより優先する
+	t.Setenv("LOG_LEVEL", "warn")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Database.Driver != DriverSQLite || cfg.Database.Path != "/tmp/test.db" || cfg.Log.SlowQuery != time.Second {
+		t.Errorf("config = %+v, want the values from the file", cfg.Database)
+	}
+	if cfg.Log.Level != "warn" {
+		t.Errorf("log level = %q, want warn", cfg.Log.Level)
+	}
+
+	// 綴りを誤ったキーは無視せずにエラーにする
+	t.Setenv("CONFIG_FILE", write("typo.yaml", "database:\n  drvier: sqlite\n"))
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "drvier") {
+		t.Errorf("Load error = %v, want one naming the unknown key", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"mysql without host", func(c *Config) {
+			c.Database = Database{Driver: DriverMySQL, Port: "3306", User: "app", Name: "db", MaxOpenConns: 1}
+		}, "DB_HOST is required for DB_DRIVER=mysql"},
+		{"postgres without host", func(c *Config) {
+			c.Database = Database{Driver: DriverPostgres, Port: "5432", User: "app", Name: "db", MaxOpenConns: 1}
+		}, "DB_HOST is required for DB_DRIVER=postgres"},
+		{"unknown driver", func(c *Config) { c.Database.Driver = "oracle" }, "DB_DRIVER must be"},
+		{"idle above open", func(c *Config) { c.Database.MaxIdleConns = c.Database.MaxOpenConns + 1 }, "DB_MAX_IDLE_CONNS"},
+		{"port out of range", func(c *Config) { c.Server.Port = "70000" }, "PORT must be a port number"},
+		{"zero timeout", func(c *Config) { c.Server.WriteTimeout = 0 }, "SERVER_WRITE_TIMEOUT must be positive"},
+		{"s3 without bucket", func(c *Config) {
+			c.Storage.Driver = StorageS3
+			c.Storage.S3.Endpoint = "s3.example.com"
+		}, "S3_BUCKET is required"},
+		{"unknown log level", func(c *Config) { c.Log.Level = "verbose" }, "LOG_LEVEL"},
+		{"sample ratio above one", func(c *Config) { c.Tracing.SampleRatio = 2 }, "TRACING_SAMPLE_RATIO"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+
+	if err := validConfig().Validate(); err != nil {
+		t.Errorf("Validate valid config: %v", err)
+	}
+
+	// 問題はまとめて報告する
+	cfg := validConfig()
+	cfg.Server.Port = "http"
+	cfg.Log.Format = "xml"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "PORT") || !strings.Contains(err.Error(), "LOG_FORMAT") {
+		t.Errorf("Validate error = %v, want both problems", err)
+	}
+}
+
+// validConfig 検証を通る既定値ベースの設定
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Database.Host = "db"
+	cfg.Database.User = "app"
+	cfg.Database.Name = "challecara"
+	cfg.Database.applyDriverDefaults()
+	return cfg
+}
+
+func TestLogValueRedactsSecrets(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DB_DRIVER", "mysql")
+	t.Setenv("DB_HOST", "db")
+	t.Setenv("DB_USER", "app")
+	t.Setenv("DB_NAME", "challecara")
+	t.Setenv("DB_PASSWORD", "db-secret")
+	t.Setenv("S3_ACCESS_KEY_ID", "access")
+	t.Setenv("S3_SECRET_ACCESS_KEY", "s3-secret")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	values := map[string]string{}
+	for _, attr := range cfg.LogValue().Group() {
+		values[attr.Key] = attr.Value.String()
+	}
+
+	want := map[string]string{
+		"database.password":            redacted,
+		"storage.s3.secret_access_key": redacted,
+		"database.host":                "db",
+		"storage.s3.access_key_id":     "access",
+		"server.read_timeout":          "30s",
+	}
+	for key, value := range want {
+		if values[key] != value {
+			t.Errorf("%s = %q, want %q", key, values[key], value)
+		}
+	}
+	for key, value := range values {
+		if strings.Contains(value, "secret") {
+			t.Errorf("%s = %q leaks a secret", key, value)
+		}
+	}
+
+	// 未設定のシークレットは空のまま出す
+	cfg.Database.Password = ""
+	for _, attr := range cfg.LogValue().Group() {
+		if attr.Key == "database.password" && attr.Value.String() != "" {
+			t.Errorf("empty password = %q, want empty", attr.Value.String())
+		}
+	}
+}
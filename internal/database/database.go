@@ -1,64 +1,206 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"time"
 
+	"challecara2025-back/internal/config"
+
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// 起動時の接続の再試行の間隔（失敗するたびに倍にする）
+const (
+	initialRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 10 * time.Second
+)
+
 var DB *gorm.DB
 
-// Connect データベースに接続し、マイグレーションを実行
-func Connect() error {
-	// 環境変数から接続情報を取得
-	dbHost := os.Getenv("DB_HOST")
-	dbUser := os.Getenv("DB_USER")
-	dbPassword := os.Getenv("DB_PASSWORD")
-	dbName := os.Getenv("DB_NAME")
-	dbPort := os.Getenv("DB_PORT")
-
-	if dbPort == "" {
-		dbPort = "3306"
+// Connect データベースに接続し、接続プールを設定する。
+// データベースの起動を待てるよう、cfg.ConnectTimeout の間は間隔を空けながら再試行する
+func Connect(cfg config.Database, log logger.Interface) error {
+	dialector, err := dialectorFor(cfg)
+	if err != nil {
+		return err
 	}
 
-	// DSN (Data Source Name) を作成
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		dbUser,
-		dbPassword,
-		dbHost,
-		dbPort,
-		dbName,
-	)
-
-	// データベースに接続
-	var err error
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
+	deadline := time.Now().Add(cfg.ConnectTimeout)
+	delay := initialRetryDelay
+	for attempt := 1; ; attempt++ {
+		DB, err = open(dialector, log)
+		if err == nil {
+			break
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("failed to connect to database after %d attempts: %w", attempt, err)
+		}
+		slog.Warn("Database is not reachable yet", "attempt", attempt, "retry_in", delay.String(), "error", err)
+		time.Sleep(delay)
+		delay = min(delay*2, maxRetryDelay)
+	}
 
+	sqlDB, err := DB.DB()
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return fmt.Errorf("failed to configure database: %w", err)
+	}
+	if cfg.Driver == config.DriverSQLite {
+		pinSingleConnection(sqlDB)
+	} else {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 	}
 
-	log.Println("Database connection established")
+	slog.Info("Database connection established", "driver", cfg.Driver)
 	return nil
 }
 
-// Migrate データベースのマイグレーションを実行
-func Migrate(models ...interface{}) error {
-	err := DB.AutoMigrate(models...)
+// open 接続を開き、疎通を確認する（失敗した場合は開いた接続を閉じる）
+func open(dialector gorm.Dialector, log logger.Interface) (*gorm.DB, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: log,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to migrate database: %w", err)
+		if db != nil {
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				sqlDB.Close()
+			}
+		}
+		return nil, err
 	}
-	log.Println("Database migration completed")
-	return nil
+	return db, nil
+}
+
+// dialectorFor 接続設定からドライバーごとのDialectorを作成
+func dialectorFor(cfg config.Database) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case config.DriverMySQL:
+		// DSN (Data Source Name) を作成
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			cfg.User,
+			cfg.Password,
+			cfg.Host,
+			cfg.Port,
+			cfg.Name,
+		)
+		return mysql.Open(dsn), nil
+
+	case config.DriverPostgres:
+		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=UTC",
+			cfg.Host,
+			cfg.User,
+			cfg.Password,
+			cfg.Name,
+			cfg.Port,
+			cfg.SSLMode,
+		)
+		return postgres.Open(dsn), nil
+
+	case config.DriverSQLite:
+		return sqliteDialector(cfg.Path)
+	}
+
+	return nil, fmt.Errorf("unsupported DB_DRIVER %q (expected %s, %s or %s)", cfg.Driver, config.DriverMySQL, config.DriverPostgres, config.DriverSQLite)
+}
+
+// sqliteDialector SQLiteのDialectorを作成（":memory:"の場合はメモリ上のデータベース）
+func sqliteDialector(path string) (gorm.Dialector, error) {
+	if path != ":memory:" {
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("failed to create database directory: %w", err)
+			}
+		}
+	}
+	return sqlite.Open(path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"), nil
+}
+
+// OpenSQLite SQLiteのデータベースを開く（テストでは":memory:"を指定する）
+func OpenSQLite(path string) (*gorm.DB, error) {
+	dialector, err := sqliteDialector(path)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	pinSingleConnection(sqlDB)
+	return db, nil
+}
+
+// pinSingleConnection SQLiteの接続を1本に固定し、閉じずに使い続ける。
+// 書き込みは1接続ずつなのでロック待ちを避けられ、接続ごとに別物になるメモリ上のデータベースも失われない
+func pinSingleConnection(sqlDB *sql.DB) {
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+	sqlDB.SetConnMaxIdleTime(0)
+}
+
+// Close データベースの接続プールを閉じる
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// PoolStats 接続プールの状態
+type PoolStats struct {
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitDurationMS     int64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+}
+
+// Stats dbの接続プールの状態を返す
+func Stats(db *gorm.DB) (PoolStats, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return PoolStats{}, err
+	}
+	stats := sqlDB.Stats()
+	return PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMS:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}, nil
 }
 
 // GetDB データベースインスタンスを取得
 func GetDB() *gorm.DB {
 	return DB
-}
\ No newline at end of file
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"challecara2025-back/internal/config"
+
+	"gorm.io/gorm/logger"
+)
+
+func TestConnectSQLiteInMemoryKeepsData(t *testing.T) {
+	cfg := config.Default().Database
+	cfg.Driver = config.DriverSQLite
+	cfg.Path = ":memory:"
+	// 短い寿命を指定しても、メモリ上のデータベースの接続は閉じない
+	cfg.ConnMaxLifetime = time.Millisecond
+	cfg.ConnMaxIdleTime = time.Millisecond
+	cfg.MaxIdleConns = 0
+
+	if err := Connect(cfg, logger.Default.LogMode(logger.Silent)); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { Close() })
+
+	if err := DB.Exec("CREATE TABLE notes (body TEXT)").Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if err := DB.Exec("INSERT INTO notes (body) VALUES (?)", "kept").Error; err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	var count int64
+	if err := DB.Table("notes").Count(&count).Error; err != nil {
+		t.Fatalf("count after idle: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+
+	stats, err := Stats(DB)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.MaxOpenConnections != 1 {
+		t.Errorf("max open connections = %d, want 1", stats.MaxOpenConnections)
+	}
+	if stats.MaxLifetimeClosed != 0 || stats.MaxIdleTimeClosed != 0 || stats.MaxIdleClosed != 0 {
+		t.Errorf("connections were closed: %+v", stats)
+	}
+}
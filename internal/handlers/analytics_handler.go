@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	analyticsDateLayout  = "2006-01-02"
+	defaultAnalyticsDays = 30
+)
+
+// AnalyticsHandler 作者向けの閲覧分析を扱う
+type AnalyticsHandler struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsHandler(db *gorm.DB) *AnalyticsHandler {
+	return &AnalyticsHandler{db: db}
+}
+
+type dailyViews struct {
+	Day   time.Time `json:"day"`
+	Views int64     `json:"views"`
+}
+
+type episodeAnalytics struct {
+	EpisodeID     uuid.UUID `json:"episode_id"`
+	EpisodeNo     int       `json:"episode_no"`
+	Title         string    `json:"title"`
+	Views         int64     `json:"views"`
+	UniqueReaders int64     `json:"unique_readers"`
+	// CompletionRate 最初のエピソードを読んだ読者のうち、このエピソードまで読んだ読者の割合
+	CompletionRate float64 `json:"completion_rate"`
+	// RetentionFromPrevious 直前のエピソードを読んだ読者のうち、このエピソードも読んだ読者の割合
+	RetentionFromPrevious *float64 `json:"retention_from_previous,omitempty"`
+}
+
+type bookAnalytics struct {
+	BookID        uuid.UUID          `json:"book_id"`
+	From          string             `json:"from"`
+	To            string             `json:"to"`
+	TotalViews    int64              `json:"total_views"`
+	UniqueReaders int64              `json:"unique_readers"`
+	Daily         []dailyViews       `json:"daily"`
+	Episodes      []episodeAnalytics `json:"episodes"`
+}
+
+// GetBookAnalytics Bookの閲覧分析を取得（?from=YYYY-MM-DD&to=YYYY-MM-DD、既定は直近30日。Bookの作者のみ）
+func (h *AnalyticsHandler) GetBookAnalytics(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	bookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+
+	to := models.StartOfDay(time.Now())
+	if param := c.Query("to"); param != "" {
+		if to, err = time.Parse(analyticsDateLayout, param); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be formatted as YYYY-MM-DD"})
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -(defaultAnalyticsDays - 1))
+	if param := c.Query("from"); param != "" {
+		if from, err = time.Parse(analyticsDateLayout, param); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be formatted as YYYY-MM-DD"})
+			return
+		}
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	var book models.Book
+	if err := db.Where("id = ?", bookID).First(&book).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return
+	}
+
+	if book.AuthorID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the book author can view analytics"})
+		return
+	}
+
+	result := bookAnalytics{
+		BookID:   book.ID,
+		From:     from.Format(analyticsDateLayout),
+		To:       to.Format(analyticsDateLayout),
+		Daily:    []dailyViews{},
+		Episodes: []episodeAnalytics{},
+	}
+
+	// 日ごとの閲覧数
+	if err := db.Model(&models.EpisodeViewDaily{}).
+		Select("day, SUM(views) AS views").
+		Where("book_id = ? AND day BETWEEN ? AND ?", book.ID, from, to).
+		Group("day").
+		Order("day").
+		Scan(&result.Daily).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate views"})
+		return
+	}
+	for _, daily := range result.Daily {
+		result.TotalViews += daily.Views
+	}
+
+	views := db.Model(&models.EpisodeView{}).
+		Where("episode_views.book_id = ? AND episode_views.day BETWEEN ? AND ?", book.ID, from, to).
+		Session(&gorm.Session{})
+
+	if err := views.Distinct("viewer_key").Count(&result.UniqueReaders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate readers"})
+		return
+	}
+
+	// エピソードごとの閲覧数と読者数
+	var episodeViews []struct {
+		EpisodeID uuid.UUID
+		Views     int64
+	}
+	if err := db.Model(&models.EpisodeViewDaily{}).
+		Select("episode_id, SUM(views) AS views").
+		Where("book_id = ? AND day BETWEEN ? AND ?", book.ID, from, to).
+		Group("episode_id").
+		Scan(&episodeViews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate views"})
+		return
+	}
+	var episodeReaders []struct {
+		EpisodeID uuid.UUID
+		Readers   int64
+	}
+	if err := views.Select("episode_id, COUNT(DISTINCT viewer_key) AS readers").
+		Group("episode_id").
+		Scan(&episodeReaders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate readers"})
+		return
+	}
+	viewsByEpisode := make(map[uuid.UUID]int64, len(episodeViews))
+	for _, v := range episodeViews {
+		viewsByEpisode[v.EpisodeID] = v.Views
+	}
+	readersByEpisode := make(map[uuid.UUID]int64, len(episodeReaders))
+	for _, r := range episodeReaders {
+		readersByEpisode[r.EpisodeID] = r.Readers
+	}
+
+	var episodes []models.Episode
+	if err := db.Scopes(models.PublishedEpisodes).
+		Select("id", "episode_no", "title").
+		Where("book_id = ?", book.ID).
+		Order("episode_no").
+		Find(&episodes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episodes"})
+		return
+	}
+
+	retainedByEpisode, err := retainedReaders(db, book.ID, episodes, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate retention"})
+		return
+	}
+
+	var firstReaders int64
+	for i, episode := range episodes {
+		entry := episodeAnalytics{
+			EpisodeID:     episode.ID,
+			EpisodeNo:     episode.EpisodeNo,
+			Title:         episode.Title,
+			Views:         viewsByEpisode[episode.ID],
+			UniqueReaders: readersByEpisode[episode.ID],
+		}
+
+		if i == 0 {
+			firstReaders = entry.UniqueReaders
+		}
+		if firstReaders > 0 {
+			entry.CompletionRate = float64(entry.UniqueReaders) / float64(firstReaders)
+		}
+
+		// 直前のエピソードの読者のうち、このエピソードも読んだ読者の割合
+		if i > 0 {
+			rate := 0.0
+			if previousReaders := readersByEpisode[episodes[i-1].ID]; previousReaders > 0 {
+				rate = float64(retainedByEpisode[episode.ID]) / float64(previousReaders)
+			}
+			entry.RetentionFromPrevious = &rate
+		}
+
+		result.Episodes = append(result.Episodes, entry)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// retainedReaders エピソードごとに、直前のエピソード（episodesの並び順）と両方を期間内に読んだ読者の数を1回のクエリで集計する
+func retainedReaders(db *gorm.DB, bookID uuid.UUID, episodes []models.Episode, from, to time.Time) (map[uuid.UUID]int64, error) {
+	retained := make(map[uuid.UUID]int64, len(episodes))
+	if len(episodes) < 2 {
+		return retained, nil
+	}
+
+	// 各エピソードの直前のエピソードをCASE式で対応付ける
+	previousOf := "CASE cur.episode_id"
+	args := make([]interface{}, 0, 2*(len(episodes)-1))
+	for i := 1; i < len(episodes); i++ {
+		previousOf += " WHEN ? THEN ?"
+		args = append(args, episodes[i].ID, episodes[i-1].ID)
+	}
+	previousOf += " END"
+
+	var rows []struct {
+		EpisodeID uuid.UUID
+		Retained  int64
+	}
+	err := db.Table("episode_views AS cur").
+		Select("cur.episode_id, COUNT(DISTINCT cur.viewer_key) AS retained").
+		Joins("JOIN episode_views AS prev ON prev.viewer_key = cur.viewer_key AND prev.book_id = cur.book_id AND prev.day BETWEEN ? AND ?", from, to).
+		Where("cur.book_id = ? AND cur.day BETWEEN ? AND ?", bookID, from, to).
+		Where("prev.episode_id = "+previousOf, args...).
+		Group("cur.episode_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		retained[row.EpisodeID] = row.Retained
+	}
+	return retained, nil
+}
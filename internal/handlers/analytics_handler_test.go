@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestGetBookAnalytics(t *testing.T) {
+	db := newTestDB(t)
+	router := gin.New()
+	router.GET("/books/:id/analytics", NewAnalyticsHandler(db).GetBookAnalytics)
+
+	authorID := uuid.New()
+	book := models.Book{ID: uuid.New(), Title: "Book", AuthorID: authorID, Status: models.BookStatusPublished}
+	insert(t, db, &book)
+
+	var episodes []models.Episode
+	for no := 1; no <= 3; no++ {
+		episode := models.Episode{ID: uuid.New(), BookID: book.ID, Title: "Episode", EpisodeNo: no, Status: models.EpisodeStatusPublished}
+		insert(t, db, &episode)
+		episodes = append(episodes, episode)
+	}
+
+	// 第1話: a b c d、第2話: a b c、第3話: a と 第2話を読んでいない e
+	day := models.StartOfDay(time.Now())
+	readers := [][]string{{"a", "b", "c", "d"}, {"a", "b", "c"}, {"a", "e"}}
+	for i, keys := range readers {
+		for _, key := range keys {
+			insert(t, db, &models.EpisodeView{ID: uuid.New(), EpisodeID: episodes[i].ID, BookID: book.ID, ViewerKey: key, Day: day})
+		}
+		insert(t, db, &models.EpisodeViewDaily{EpisodeID: episodes[i].ID, BookID: book.ID, Day: day, Views: int64(len(keys))})
+	}
+
+	path := "/books/" + book.ID.String() + "/analytics"
+
+	rec := serve(t, router, http.MethodGet, path, nil, nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("without user: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	rec = serve(t, router, http.MethodGet, path, nil, asUser(uuid.New()))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("other user: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	rec = serve(t, router, http.MethodGet, path, nil, asUser(authorID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("author: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var got bookAnalytics
+	decode(t, rec, &got)
+	if got.TotalViews != 9 || got.UniqueReaders != 5 {
+		t.Errorf("total_views = %d, unique_readers = %d, want 9 and 5", got.TotalViews, got.UniqueReaders)
+	}
+	if len(got.Episodes) != 3 {
+		t.Fatalf("episodes = %+v, want 3", got.Episodes)
+	}
+
+	wantRetention := []*float64{nil, ptr(3.0 / 4), ptr(1.0 / 3)}
+	for i, entry := range got.Episodes {
+		want := wantRetention[i]
+		switch {
+		case want == nil && entry.RetentionFromPrevious != nil:
+			t.Errorf("episode %d retention = %v, want none", i+1, *entry.RetentionFromPrevious)
+		case want != nil && (entry.RetentionFromPrevious == nil || *entry.RetentionFromPrevious != *want):
+			t.Errorf("episode %d retention = %v, want %v", i+1, entry.RetentionFromPrevious, *want)
+		}
+	}
+	if got.Episodes[2].CompletionRate != 0.5 {
+		t.Errorf("episode 3 completion_rate = %v, want 0.5", got.Episodes[2].CompletionRate)
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"challecara2025-back/internal/metrics"
+	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxAttachmentSize 添付ファイル1件あたりの最大サイズ
+const MaxAttachmentSize = 10 << 20
+
+// allowedAttachmentTypes 内容から判定したContent-Typeのうち添付を許可するもの
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+// AttachmentHandler 参考資料の添付ファイルを扱う
+type AttachmentHandler struct {
+	db      *gorm.DB
+	storage storage.Storage
+	quota   int64
+}
+
+func NewAttachmentHandler(db *gorm.DB, store storage.Storage, quota int64) *AttachmentHandler {
+	return &AttachmentHandler{db: db, storage: store, quota: quota}
+}
+
+// UploadAttachment 参考資料にファイルを添付
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	materialID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+		return
+	}
+
+	var material models.Material
+	if err := db.Where("id = ?", materialID).First(&material).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Material not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch material"})
+		return
+	}
+
+	data, fileHeader, ok := readUpload(c, MaxAttachmentSize)
+	if !ok {
+		return
+	}
+
+	// クライアントが申告したContent-Typeではなく内容から判定する
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil || !allowedAttachmentTypes[contentType] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Unsupported file type"})
+		return
+	}
+
+	authorID, err := h.materialOwner(c.Request.Context(), &material)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve material owner"})
+		return
+	}
+	// 所有者の無い資料の添付を許すと、すべて同じ容量制限に数えられてしまう
+	if authorID == uuid.Nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Material has no author; set the author of its book or series before attaching files"})
+		return
+	}
+
+	// Generate UUIDv7 for the new attachment
+	newID, err := uuid.NewV7()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate UUID"})
+		return
+	}
+
+	attachment := models.Attachment{
+		ID:          newID,
+		MaterialID:  material.ID,
+		AuthorID:    authorID,
+		FileName:    sanitizeFileName(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		StorageKey:  "materials/" + material.ID.String() + "/attachments/" + newID.String(),
+	}
+
+	if err := h.storage.Put(c.Request.Context(), attachment.StorageKey, bytes.NewReader(data), attachment.Size, contentType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store attachment"})
+		return
+	}
+
+	// 同じ作者の同時アップロードで上限を超えないよう、作者の行をロックしてから使用量を確認して登録する
+	var used int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := lockAuthorUsage(tx, authorID); err != nil {
+			return err
+		}
+		var err error
+		if used, err = attachmentUsage(tx, authorID); err != nil {
+			return err
+		}
+		if used+attachment.Size > h.quota {
+			return errQuotaExceeded
+		}
+		return tx.Create(&attachment).Error
+	})
+	if err != nil {
+		deleteObjects(h.storage, attachment.StorageKey)
+		if errors.Is(err, errQuotaExceeded) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "Attachment quota exceeded",
+				"used":  used,
+				"quota": h.quota,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attachment"})
+		return
+	}
+	metrics.UploadedBytes.WithLabelValues("attachment").Add(float64(attachment.Size))
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GetAttachments 参考資料の添付ファイル一覧を取得
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	materialID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+		return
+	}
+
+	if err := db.Where("id = ?", materialID).First(&models.Material{}).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Material not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch material"})
+		return
+	}
+
+	var attachments []models.Attachment
+	if err := db.Where("material_id = ?", materialID).Order("created_at").Find(&attachments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// DownloadAttachment 添付ファイルをダウンロード
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	attachment, ok := h.findAttachment(c)
+	if !ok {
+		return
+	}
+
+	reader, err := h.storage.Get(c.Request.Context(), attachment.StorageKey)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment file not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read attachment"})
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, reader, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteAttachment 添付ファイルを削除
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	attachment, ok := h.findAttachment(c)
+	if !ok {
+		return
+	}
+
+	if err := db.Delete(&attachment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+	deleteObjects(h.storage, attachment.StorageKey)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
+
+// GetAuthorUsage 作者の添付ファイルの使用量と上限を取得
+func (h *AttachmentHandler) GetAuthorUsage(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	authorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author ID"})
+		return
+	}
+
+	used, err := attachmentUsage(db, authorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate storage usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"author_id": authorID, "used": used, "quota": h.quota})
+}
+
+func (h *AttachmentHandler) findAttachment(c *gin.Context) (models.Attachment, bool) {
+	db := h.db.WithContext(c.Request.Context())
+
+	var attachment models.Attachment
+
+	attachmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return attachment, false
+	}
+
+	if err := db.Where("id = ?", attachmentID).First(&attachment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return attachment, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachment"})
+		return attachment, false
+	}
+
+	return attachment, true
+}
+
+// errQuotaExceeded 添付すると作者の容量制限を超える
+var errQuotaExceeded = errors.New("attachment quota exceeded")
+
+// attachmentUsage 作者の添付ファイルの合計サイズ
+func attachmentUsage(db *gorm.DB, authorID uuid.UUID) (int64, error) {
+	var used int64
+	err := db.Model(&models.Attachment{}).
+		Where("author_id = ?", authorID).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&used).Error
+	return used, err
+}
+
+// lockAuthorUsage 作者の添付ファイル用のロック行をトランザクションの終わりまでロックする（行が無ければ作成する）。
+// 添付ファイルの使用量の確認と登録を作者ごとに1つずつ行うために使う（フォロワー数などの集計行とは競合しない）
+func lockAuthorUsage(tx *gorm.DB, authorID uuid.UUID) error {
+	row := models.AttachmentQuotaLock{AuthorID: authorID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+		return err
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("author_id = ?", authorID).First(&row).Error
+}
+
+// materialOwner 参考資料の所有者（作者ライブラリ・シリーズ・Bookの作者）を返す（作者が設定されていなければuuid.Nil）
+func (h *AttachmentHandler) materialOwner(ctx context.Context, material *models.Material) (uuid.UUID, error) {
+	switch {
+	case material.AuthorID != nil:
+		return *material.AuthorID, nil
+	case material.SeriesID != nil:
+		var series models.Series
+		if err := h.db.WithContext(ctx).Unscoped().Select("author_id").Where("id = ?", *material.SeriesID).First(&series).Error; err != nil {
+			return uuid.Nil, err
+		}
+		return series.AuthorID, nil
+	case material.BookID != nil:
+		var book models.Book
+		if err := h.db.WithContext(ctx).Unscoped().Select("author_id").Where("id = ?", *material.BookID).First(&book).Error; err != nil {
+			return uuid.Nil, err
+		}
+		return book.AuthorID, nil
+	}
+	return uuid.Nil, nil
+}
+
+// sanitizeFileName パス区切りや制御文字を取り除いたファイル名を返す
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		name = "file"
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[len(runes)-255:])
+	}
+	return name
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func newAttachmentRouter(t *testing.T, db *gorm.DB, quota int64) *gin.Engine {
+	t.Helper()
+	store, err := storage.NewLocalStorage(t.TempDir(), storage.LocalURLPrefix)
+	if err != nil {
+		t.Fatalf("create storage: %v", err)
+	}
+	h := NewAttachmentHandler(db, store, quota)
+	router := gin.New()
+	router.POST("/materials/:id/attachments", h.UploadAttachment)
+	router.GET("/authors/:id/usage", h.GetAuthorUsage)
+	return router
+}
+
+// uploadAttachment 添付ファイルをマルチパートでアップロードする
+func uploadAttachment(t *testing.T, router http.Handler, materialID uuid.UUID, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "notes.txt")
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	part.Write(content)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/materials/"+materialID.String()+"/attachments", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUploadAttachmentQuota(t *testing.T) {
+	db := newTestDB(t)
+	router := newAttachmentRouter(t, db, 10)
+	authorID := uuid.New()
+	material := models.Material{ID: uuid.New(), AuthorID: &authorID, Title: "Notes", Content: "..."}
+	insert(t, db, &material)
+
+	if rec := uploadAttachment(t, router, material.ID, []byte("123456")); rec.Code != http.StatusCreated {
+		t.Fatalf("first upload: status = %d, want %d (body %s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	if rec := uploadAttachment(t, router, material.ID, []byte("123456")); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("second upload: status = %d, want %d (body %s)", rec.Code, http.StatusRequestEntityTooLarge, rec.Body.String())
+	}
+
+	used, err := attachmentUsage(db, authorID)
+	if err != nil {
+		t.Fatalf("attachmentUsage: %v", err)
+	}
+	if used != 6 {
+		t.Errorf("used = %d, want 6", used)
+	}
+
+	// ロックには専用の行を使い、フォロワー数の集計行は作らない
+	var locks, stats int64
+	db.Model(&models.AttachmentQuotaLock{}).Where("author_id = ?", authorID).Count(&locks)
+	db.Model(&models.AuthorStats{}).Where("author_id = ?", authorID).Count(&stats)
+	if locks != 1 || stats != 0 {
+		t.Errorf("quota lock rows = %d, author stats rows = %d, want 1 and 0", locks, stats)
+	}
+}
+
+func TestUploadAttachmentConcurrentQuota(t *testing.T) {
+	db := newTestDB(t)
+	router := newAttachmentRouter(t, db, 30)
+	authorID := uuid.New()
+	material := models.Material{ID: uuid.New(), AuthorID: &authorID, Title: "Notes", Content: "..."}
+	insert(t, db, &material)
+
+	// 10バイトずつ6件を同時に送っても、上限の30バイトまでの3件だけが登録される
+	var wg sync.WaitGroup
+	codes := make([]int, 6)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = uploadAttachment(t, router, material.ID, []byte("0123456789")).Code
+		}()
+	}
+	wg.Wait()
+
+	created := 0
+	for _, code := range codes {
+		if code == http.StatusCreated {
+			created++
+		}
+	}
+	used, err := attachmentUsage(db, authorID)
+	if err != nil {
+		t.Fatalf("attachmentUsage: %v", err)
+	}
+	if created != 3 || used != 30 {
+		t.Errorf("created = %d, used = %d, want 3 uploads using 30 bytes (codes %v)", created, used, codes)
+	}
+}
+
+func TestUploadAttachmentWithoutOwner(t *testing.T) {
+	db := newTestDB(t)
+	router := newAttachmentRouter(t, db, 1<<20)
+	book := models.Book{ID: uuid.New(), Title: "Book", Status: models.BookStatusDraft}
+	material := models.Material{ID: uuid.New(), BookID: &book.ID, Title: "Notes", Content: "..."}
+	insert(t, db, &book, &material)
+
+	rec := uploadAttachment(t, router, material.ID, []byte("notes"))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusUnprocessableEntity, rec.Body.String())
+	}
+
+	var count int64
+	db.Model(&models.Attachment{}).Count(&count)
+	if count != 0 {
+		t.Errorf("attachments = %d, want 0", count)
+	}
+}
@@ -1,54 +1,81 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
+	"challecara2025-back/internal/metrics"
 	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/repository"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
 type BookHandler struct {
-	db *gorm.DB
+	books repository.BookRepository
 }
 
-func NewBookHandler(db *gorm.DB) *BookHandler {
-	return &BookHandler{db: db}
+func NewBookHandler(books repository.BookRepository) *BookHandler {
+	return &BookHandler{books: books}
+}
+
+type bookStatusInput struct {
+	Status string `json:"status" binding:"required"`
 }
 
 // CreateBook 新しい資料を作成
 func (h *BookHandler) CreateBook(c *gin.Context) {
 	var book models.Book
 
-	if err := c.ShouldBindJSON(&book); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &book) {
 		return
 	}
 
 	// Generate UUIDv7 for the new book
 	newID, err := uuid.NewV7()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate UUID"})
+		respondInternalError(c, "Failed to generate UUID")
 		return
 	}
 	book.ID = newID
+	book.Stats = nil
+	// シリーズへの追加・並び替えはシリーズのAPI経由でのみ行う
+	book.SeriesID = nil
+	book.SeriesOrder = 0
+	// 表紙は表紙のAPIでアップロードする
+	book.CoverImage = ""
+	book.CoverThumb = ""
+
+	// 作成時はdraftから指定されたステータスへ遷移させる
+	status := book.Status
+	if status == "" {
+		status = models.BookStatusDraft
+	}
+	book.Status = models.BookStatusDraft
+	book.PublishedAt = nil
+	book.CompletedAt = nil
+	if err := book.TransitionStatus(status, time.Now()); err != nil {
+		respondStatusError(c, models.BookStatusDraft, status, err)
+		return
+	}
 
-	if err := h.db.Create(&book).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create book"})
+	if err := h.books.Create(c.Request.Context(), &book); err != nil {
+		respondInternalError(c, "Failed to create book")
 		return
 	}
+	metrics.BooksCreated.Inc()
 
 	c.JSON(http.StatusCreated, book)
 }
 
 // GetBooks すべての資料を取得
 func (h *BookHandler) GetBooks(c *gin.Context) {
-	var books []models.Book
-
-	if err := h.db.Preload("Episodes").Preload("Materials").Find(&books).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch books"})
+	books, err := h.books.List(c.Request.Context())
+	if err != nil {
+		respondInternalError(c, "Failed to fetch books")
 		return
 	}
 
@@ -58,71 +85,154 @@ func (h *BookHandler) GetBooks(c *gin.Context) {
 // GetBook 特定の資料を取得
 func (h *BookHandler) GetBook(c *gin.Context) {
 	id := c.Param("id")
-	var book models.Book
 
 	bookID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		respondInvalidID(c, "book")
 		return
 	}
 
-	if err := h.db.Preload("Episodes").Preload("Materials").Where("id = ?", bookID).First(&book).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+	book, err := h.books.GetWithContents(c.Request.Context(), bookID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondNotFound(c, "Book not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		respondInternalError(c, "Failed to fetch book")
 		return
 	}
 
+	// いいね・お気に入り数と作者のフォロワー数はカウンタテーブルから返す
+	stats, err := h.books.Stats(c.Request.Context(), book.ID)
+	if err != nil {
+		respondInternalError(c, "Failed to fetch book stats")
+		return
+	}
+	authorStats, err := h.books.AuthorStats(c.Request.Context(), book.AuthorID)
+	if err != nil {
+		respondInternalError(c, "Failed to fetch author stats")
+		return
+	}
+	book.Stats = stats
+	book.AuthorStats = authorStats
+
 	c.JSON(http.StatusOK, book)
 }
 
 // UpdateBook 資料を更新
 func (h *BookHandler) UpdateBook(c *gin.Context) {
 	id := c.Param("id")
-	var book models.Book
 
 	bookID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		respondInvalidID(c, "book")
+		return
+	}
+
+	book, err := h.books.Get(c.Request.Context(), bookID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondNotFound(c, "Book not found")
+			return
+		}
+		respondInternalError(c, "Failed to fetch book")
+		return
+	}
+
+	// ステータスと公開日時はTransitionStatus経由、シリーズはシリーズのAPI経由、表紙は表紙のAPI経由でのみ変更する
+	current := *book
+	if !bindJSON(c, book) {
+		return
+	}
+	status := book.Status
+	book.Stats = nil
+	book.Status = current.Status
+	book.PublishedAt = current.PublishedAt
+	book.CompletedAt = current.CompletedAt
+	book.SeriesID = current.SeriesID
+	book.SeriesOrder = current.SeriesOrder
+	book.CoverImage = current.CoverImage
+	book.CoverThumb = current.CoverThumb
+	book.CoverKey = current.CoverKey
+	book.ThumbKey = current.ThumbKey
+
+	if status != "" {
+		if err := book.TransitionStatus(status, time.Now()); err != nil {
+			respondStatusError(c, current.Status, status, err)
+			return
+		}
+	}
+
+	if err := h.books.Update(c.Request.Context(), book); err != nil {
+		respondInternalError(c, "Failed to update book")
+		return
+	}
+
+	c.JSON(http.StatusOK, book)
+}
+
+// UpdateBookStatus 資料の公開ステータスのみを更新
+func (h *BookHandler) UpdateBookStatus(c *gin.Context) {
+	id := c.Param("id")
+
+	bookID, err := uuid.Parse(id)
+	if err != nil {
+		respondInvalidID(c, "book")
+		return
+	}
+
+	var input bookStatusInput
+	if !bindJSON(c, &input) {
 		return
 	}
 
-	if err := h.db.Where("id = ?", bookID).First(&book).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+	book, err := h.books.Get(c.Request.Context(), bookID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondNotFound(c, "Book not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		respondInternalError(c, "Failed to fetch book")
 		return
 	}
 
-	if err := c.ShouldBindJSON(&book); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	from := book.Status
+	if err := book.TransitionStatus(input.Status, time.Now()); err != nil {
+		respondStatusError(c, from, input.Status, err)
 		return
 	}
 
-	if err := h.db.Save(&book).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update book"})
+	if err := h.books.UpdateStatus(c.Request.Context(), book); err != nil {
+		respondInternalError(c, "Failed to update book status")
 		return
 	}
 
 	c.JSON(http.StatusOK, book)
 }
 
+// respondStatusError ステータス遷移のエラーを422で返す
+func respondStatusError(c *gin.Context, from, to string, err error) {
+	if errors.Is(err, models.ErrInvalidBookStatus) {
+		respondProblem(c, NewProblem(http.StatusUnprocessableEntity, CodeInvalidStatus, fmt.Sprintf("Invalid book status: %q", to)))
+		return
+	}
+	respondProblem(c, NewProblem(http.StatusUnprocessableEntity, CodeInvalidStatusTransition,
+		fmt.Sprintf("Cannot change book status from %q to %q", from, to)).
+		With("allowed", models.NextBookStatuses(from)))
+}
+
 // DeleteBook 資料を削除
 func (h *BookHandler) DeleteBook(c *gin.Context) {
 	id := c.Param("id")
 
 	bookID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		respondInvalidID(c, "book")
 		return
 	}
 
-	if err := h.db.Where("id = ?", bookID).Delete(&models.Book{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete book"})
+	if err := h.books.Delete(c.Request.Context(), bookID); err != nil {
+		respondInternalError(c, "Failed to delete book")
 		return
 	}
 
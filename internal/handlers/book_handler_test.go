@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func newBookRouter(books repository.BookRepository) *gin.Engine {
+	h := NewBookHandler(books)
+	router := gin.New()
+	router.POST("/books", h.CreateBook)
+	router.GET("/books", h.GetBooks)
+	router.GET("/books/:id", h.GetBook)
+	router.PUT("/books/:id", h.UpdateBook)
+	router.PUT("/books/:id/status", h.UpdateBookStatus)
+	router.DELETE("/books/:id", h.DeleteBook)
+	return router
+}
+
+// putBook リポジトリにBookを直接登録する
+func putBook(t *testing.T, books repository.BookRepository, book models.Book) models.Book {
+	t.Helper()
+	if book.ID == uuid.Nil {
+		book.ID = uuid.New()
+	}
+	if book.Status == "" {
+		book.Status = models.BookStatusDraft
+	}
+	if err := books.Create(context.Background(), &book); err != nil {
+		t.Fatalf("create book: %v", err)
+	}
+	return book
+}
+
+func TestCreateBook(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	router := newBookRouter(books)
+
+	rec := serve(t, router, http.MethodPost, "/books", map[string]interface{}{
+		"title": "Book",
+		"stats": map[string]interface{}{"like_count": 100},
+	}, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+
+	var created models.Book
+	decode(t, rec, &created)
+	if created.ID == uuid.Nil {
+		t.Fatal("id was not generated")
+	}
+	if created.Status != models.BookStatusDraft {
+		t.Errorf("status = %q, want %q", created.Status, models.BookStatusDraft)
+	}
+	if created.Stats != nil {
+		t.Errorf("stats = %+v, want nil", created.Stats)
+	}
+
+	stored, err := books.Get(context.Background(), created.ID)
+	if err != nil {
+		t.Fatalf("book was not stored: %v", err)
+	}
+	if stored.Title != "Book" {
+		t.Errorf("stored title = %q, want %q", stored.Title, "Book")
+	}
+}
+
+func TestCreateBookPublished(t *testing.T) {
+	router := newBookRouter(repository.NewMemoryBookRepository())
+
+	rec := serve(t, router, http.MethodPost, "/books", map[string]interface{}{
+		"title":  "Book",
+		"status": models.BookStatusPublished,
+	}, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+
+	var created models.Book
+	decode(t, rec, &created)
+	if created.Status != models.BookStatusPublished || created.PublishedAt == nil {
+		t.Errorf("status = %q, published_at = %v, want published with published_at", created.Status, created.PublishedAt)
+	}
+}
+
+func TestCreateBookInvalidStatus(t *testing.T) {
+	router := newBookRouter(repository.NewMemoryBookRepository())
+
+	rec := serve(t, router, http.MethodPost, "/books", map[string]interface{}{
+		"title":  "Book",
+		"status": "archived",
+	}, nil)
+	expectProblem(t, rec, http.StatusUnprocessableEntity, CodeInvalidStatus)
+}
+
+func TestCreateBookInvalidBody(t *testing.T) {
+	router := newBookRouter(repository.NewMemoryBookRepository())
+
+	rec := serve(t, router, http.MethodPost, "/books", `{"title": 1}`, nil)
+	problem := expectProblem(t, rec, http.StatusBadRequest, CodeValidationFailed)
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "title" {
+		t.Errorf("errors = %+v, want one error for title", problem.Errors)
+	}
+
+	rec = serve(t, router, http.MethodPost, "/books", `{"title":`, nil)
+	expectProblem(t, rec, http.StatusBadRequest, CodeInvalidBody)
+}
+
+func TestGetBook(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	router := newBookRouter(books)
+	book := putBook(t, books, models.Book{Title: "Book", AuthorID: uuid.New()})
+	books.PutStats(models.BookStats{BookID: book.ID, LikeCount: 3})
+	books.PutAuthorStats(models.AuthorStats{AuthorID: book.AuthorID, FollowerCount: 5})
+
+	rec := serve(t, router, http.MethodGet, "/books/"+book.ID.String(), nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var got models.Book
+	decode(t, rec, &got)
+	if got.Stats == nil || got.Stats.LikeCount != 3 {
+		t.Errorf("stats = %+v, want like_count 3", got.Stats)
+	}
+	if got.AuthorStats == nil || got.AuthorStats.FollowerCount != 5 {
+		t.Errorf("author_stats = %+v, want follower_count 5", got.AuthorStats)
+	}
+}
+
+func TestGetBookErrors(t *testing.T) {
+	router := newBookRouter(repository.NewMemoryBookRepository())
+
+	rec := serve(t, router, http.MethodGet, "/books/not-a-uuid", nil, nil)
+	expectProblem(t, rec, http.StatusBadRequest, CodeInvalidID)
+
+	rec = serve(t, router, http.MethodGet, "/books/"+uuid.NewString(), nil, nil)
+	expectProblem(t, rec, http.StatusNotFound, CodeNotFound)
+}
+
+func TestUpdateBookKeepsStatusFields(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	router := newBookRouter(books)
+	book := putBook(t, books, models.Book{Title: "Book"})
+
+	// ステータスを指定しなければ変更しない
+	rec := serve(t, router, http.MethodPut, "/books/"+book.ID.String(), map[string]interface{}{
+		"title":        "Renamed",
+		"published_at": "2020-01-01T00:00:00Z",
+	}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	stored, _ := books.Get(context.Background(), book.ID)
+	if stored.Title != "Renamed" {
+		t.Errorf("title = %q, want %q", stored.Title, "Renamed")
+	}
+	if stored.Status != models.BookStatusDraft || stored.PublishedAt != nil {
+		t.Errorf("status = %q, published_at = %v, want unchanged draft", stored.Status, stored.PublishedAt)
+	}
+
+	// 遷移できないステータスは422
+	rec = serve(t, router, http.MethodPut, "/books/"+book.ID.String(), map[string]interface{}{
+		"title":  "Renamed",
+		"status": models.BookStatusCompleted,
+	}, nil)
+	expectProblem(t, rec, http.StatusUnprocessableEntity, CodeInvalidStatusTransition)
+}
+
+func TestBookSeriesFieldsAreReadOnly(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	router := newBookRouter(books)
+	seriesID := uuid.New()
+
+	rec := serve(t, router, http.MethodPost, "/books", map[string]interface{}{
+		"title":        "Book",
+		"series_id":    seriesID,
+		"series_order": 3,
+	}, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d, want %d (body %s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var created models.Book
+	decode(t, rec, &created)
+	if created.SeriesID != nil || created.SeriesOrder != 0 {
+		t.Errorf("created series_id = %v, series_order = %d, want none", created.SeriesID, created.SeriesOrder)
+	}
+
+	// シリーズに含まれる巻を更新しても、シリーズと巻数は変わらない
+	inSeries := putBook(t, books, models.Book{Title: "Book", SeriesID: &seriesID, SeriesOrder: 1})
+	rec = serve(t, router, http.MethodPut, "/books/"+inSeries.ID.String(), map[string]interface{}{
+		"title":        "Renamed",
+		"series_id":    nil,
+		"series_order": 5,
+	}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	stored, _ := books.Get(context.Background(), inSeries.ID)
+	if stored.SeriesID == nil || *stored.SeriesID != seriesID || stored.SeriesOrder != 1 {
+		t.Errorf("stored series_id = %v, series_order = %d, want %s and 1", stored.SeriesID, stored.SeriesOrder, seriesID)
+	}
+}
+
+func TestBookCoverFieldsAreReadOnly(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	router := newBookRouter(books)
+
+	rec := serve(t, router, http.MethodPost, "/books", map[string]interface{}{
+		"title":           "Book",
+		"cover_image":     "https://example.com/cover.png",
+		"cover_thumbnail": "https://example.com/thumb.png",
+	}, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d, want %d (body %s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var created models.Book
+	decode(t, rec, &created)
+	if created.CoverImage != "" || created.CoverThumb != "" {
+		t.Errorf("created cover = %q %q, want none", created.CoverImage, created.CoverThumb)
+	}
+
+	// 表紙のある巻を更新しても、表紙は変わらない
+	withCover := putBook(t, books, models.Book{
+		Title: "Book", CoverImage: "/uploads/cover.png", CoverThumb: "/uploads/thumb.png",
+		CoverKey: "cover.png", ThumbKey: "thumb.png",
+	})
+	rec = serve(t, router, http.MethodPut, "/books/"+withCover.ID.String(), map[string]interface{}{
+		"title":           "Renamed",
+		"cover_image":     "https://example.com/cover.png",
+		"cover_thumbnail": "",
+	}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var updated models.Book
+	decode(t, rec, &updated)
+	stored, _ := books.Get(context.Background(), withCover.ID)
+	for _, got := range []*models.Book{&updated, stored} {
+		if got.CoverImage != withCover.CoverImage || got.CoverThumb != withCover.CoverThumb {
+			t.Errorf("cover = %q %q, want %q %q", got.CoverImage, got.CoverThumb, withCover.CoverImage, withCover.CoverThumb)
+		}
+	}
+	if stored.CoverKey != withCover.CoverKey || stored.ThumbKey != withCover.ThumbKey {
+		t.Errorf("stored keys = %q %q, want unchanged", stored.CoverKey, stored.ThumbKey)
+	}
+}
+
+func TestUpdateBookStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		from        string
+		to          string
+		wantStatus  int
+		wantCode    string
+		wantAllowed []string
+	}{
+		{name: "publish", from: models.BookStatusDraft, to: models.BookStatusPublished, wantStatus: http.StatusOK},
+		{name: "complete", from: models.BookStatusPublished, to: models.BookStatusCompleted, wantStatus: http.StatusOK},
+		{name: "same status", from: models.BookStatusHiatus, to: models.BookStatusHiatus, wantStatus: http.StatusOK},
+		{
+			name: "skip publish", from: models.BookStatusDraft, to: models.BookStatusCompleted,
+			wantStatus: http.StatusUnprocessableEntity, wantCode: CodeInvalidStatusTransition,
+			wantAllowed: []string{models.BookStatusPublished},
+		},
+		{
+			name: "unknown status", from: models.BookStatusDraft, to: "archived",
+			wantStatus: http.StatusUnprocessableEntity, wantCode: CodeInvalidStatus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			books := repository.NewMemoryBookRepository()
+			router := newBookRouter(books)
+			book := putBook(t, books, models.Book{Title: "Book", Status: tt.from})
+
+			rec := serve(t, router, http.MethodPut, "/books/"+book.ID.String()+"/status",
+				map[string]string{"status": tt.to}, nil)
+
+			stored, _ := books.Get(context.Background(), book.ID)
+			if tt.wantCode != "" {
+				problem := expectProblem(t, rec, tt.wantStatus, tt.wantCode)
+				if len(problem.Allowed) != len(tt.wantAllowed) {
+					t.Errorf("allowed = %v, want %v", problem.Allowed, tt.wantAllowed)
+				}
+				if stored.Status != tt.from {
+					t.Errorf("stored status = %q, want unchanged %q", stored.Status, tt.from)
+				}
+				return
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if stored.Status != tt.to {
+				t.Errorf("stored status = %q, want %q", stored.Status, tt.to)
+			}
+		})
+	}
+}
+
+func TestDeleteBook(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	router := newBookRouter(books)
+	book := putBook(t, books, models.Book{Title: "Book"})
+
+	rec := serve(t, router, http.MethodDelete, "/books/"+book.ID.String(), nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if _, err := books.Get(context.Background(), book.ID); err != repository.ErrNotFound {
+		t.Errorf("get after delete: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"net/http"
+
+	"challecara2025-back/internal/metrics"
+	"challecara2025-back/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CommentHandler エピソードへのコメント・返信・通報・モデレーションを扱う
+type CommentHandler struct {
+	db *gorm.DB
+}
+
+func NewCommentHandler(db *gorm.DB) *CommentHandler {
+	return &CommentHandler{db: db}
+}
+
+type commentCreateInput struct {
+	Body     string     `json:"body" binding:"required,max=2000"`
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+type commentReportInput struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type commentVisibilityInput struct {
+	Hidden *bool `json:"hidden" binding:"required"`
+}
+
+// GetComments エピソードのコメントをスレッド単位でページ分割して取得
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	episode, book, ok := h.findEpisode(c)
+	if !ok {
+		return
+	}
+	viewerID := optionalUserID(c)
+	isAuthor := viewerID != uuid.Nil && viewerID == book.AuthorID
+	if episode.Status != models.EpisodeStatusPublished && !isAuthor {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+		return
+	}
+
+	page, perPage := parsePagination(c)
+
+	// 削除済みでも返信が残っているコメントはスレッドを保つためにプレースホルダーとして返す
+	query := db.Unscoped().Model(&models.Comment{}).
+		Where("episode_id = ? AND parent_id IS NULL", episode.ID).
+		Where("deleted_at IS NULL OR EXISTS (SELECT 1 FROM comments AS replies WHERE replies.parent_id = comments.id AND replies.deleted_at IS NULL)").
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count comments"})
+		return
+	}
+
+	var comments []models.Comment
+	if err := query.
+		Preload("Replies", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Order("created_at DESC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+
+	for i := range comments {
+		sanitizeComment(&comments[i], isAuthor)
+		for j := range comments[i].Replies {
+			sanitizeComment(&comments[i].Replies[j], isAuthor)
+		}
+	}
+
+	c.JSON(http.StatusOK, paginatedResponse{
+		Items:   comments,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+	})
+}
+
+// CreateComment エピソードにコメント（parent_id指定時は返信）を投稿
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	episode, book, ok := h.findEpisode(c)
+	if !ok {
+		return
+	}
+	isAuthor := userID == book.AuthorID
+	if episode.Status != models.EpisodeStatusPublished && !isAuthor {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+		return
+	}
+
+	var input commentCreateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 返信先が返信だった場合はスレッドの先頭コメントにぶら下げる
+	var parentID *uuid.UUID
+	if input.ParentID != nil {
+		var parent models.Comment
+		if err := db.Where("id = ? AND episode_id = ?", *input.ParentID, episode.ID).First(&parent).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Parent comment not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch parent comment"})
+			return
+		}
+		parentID = &parent.ID
+		if parent.ParentID != nil {
+			parentID = parent.ParentID
+		}
+	}
+
+	// Generate UUIDv7 for the new comment
+	newID, err := uuid.NewV7()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate UUID"})
+		return
+	}
+
+	comment := models.Comment{
+		ID:            newID,
+		EpisodeID:     episode.ID,
+		BookID:        book.ID,
+		UserID:        userID,
+		ParentID:      parentID,
+		Body:          input.Body,
+		IsAuthorReply: isAuthor,
+	}
+
+	if err := db.Create(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		return
+	}
+	metrics.CommentsCreated.Inc()
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// DeleteComment コメントを論理削除（投稿者本人またはBookの作者のみ）
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	comment, book, ok := h.findComment(c)
+	if !ok {
+		return
+	}
+
+	if comment.UserID != userID && book.AuthorID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot delete this comment"})
+		return
+	}
+
+	if err := db.Delete(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// ReportComment コメントを通報（同じ利用者による重複通報は数えない）
+func (h *CommentHandler) ReportComment(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	comment, _, ok := h.findComment(c)
+	if !ok {
+		return
+	}
+
+	var input commentReportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Generate UUIDv7 for the new report
+	newID, err := uuid.NewV7()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate UUID"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		report := models.CommentReport{
+			ID:        newID,
+			CommentID: comment.ID,
+			UserID:    userID,
+			Reason:    input.Reason,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&report)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&comment).UpdateColumn("report_count", gorm.Expr("report_count + ?", 1)).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment reported successfully"})
+}
+
+// UpdateCommentVisibility コメントの表示・非表示を切り替える（Bookの作者のみ）
+func (h *CommentHandler) UpdateCommentVisibility(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	comment, book, ok := h.findComment(c)
+	if !ok {
+		return
+	}
+
+	if book.AuthorID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the book author can moderate comments"})
+		return
+	}
+
+	var input commentVisibilityInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Model(&comment).Update("hidden", *input.Hidden).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// GetReportedComments Bookで通報されたコメントを通報数の多い順に取得（Bookの作者のみ）
+func (h *CommentHandler) GetReportedComments(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	bookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+
+	var book models.Book
+	if err := db.Where("id = ?", bookID).First(&book).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return
+	}
+
+	if book.AuthorID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the book author can moderate comments"})
+		return
+	}
+
+	page, perPage := parsePagination(c)
+	query := db.Model(&models.Comment{}).Where("book_id = ? AND report_count > 0", book.ID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count comments"})
+		return
+	}
+
+	var comments []models.Comment
+	if err := query.Order("report_count DESC, created_at DESC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, paginatedResponse{
+		Items:   comments,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+	})
+}
+
+// findEpisode パスパラメータのエピソードとそのBookを取得し、失敗時はレスポンスを書き込む
+func (h *CommentHandler) findEpisode(c *gin.Context) (models.Episode, models.Book, bool) {
+	db := h.db.WithContext(c.Request.Context())
+
+	var episode models.Episode
+	var book models.Book
+
+	episodeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
+		return episode, book, false
+	}
+
+	if err := db.Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+			return episode, book, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episode"})
+		return episode, book, false
+	}
+
+	if err := db.Where("id = ?", episode.BookID).First(&book).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+			return episode, book, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return episode, book, false
+	}
+
+	return episode, book, true
+}
+
+// findComment パスパラメータのコメントとそのBookを取得し、失敗時はレスポンスを書き込む
+func (h *CommentHandler) findComment(c *gin.Context) (models.Comment, models.Book, bool) {
+	db := h.db.WithContext(c.Request.Context())
+
+	var comment models.Comment
+	var book models.Book
+
+	commentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return comment, book, false
+	}
+
+	if err := db.Where("id = ?", commentID).First(&comment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return comment, book, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comment"})
+		return comment, book, false
+	}
+
+	if err := db.Where("id = ?", comment.BookID).First(&book).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+			return comment, book, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return comment, book, false
+	}
+
+	return comment, book, true
+}
+
+// sanitizeComment 削除済み・非表示のコメントの本文を伏せる（作者には非表示コメントの本文と通報数を見せる）
+func sanitizeComment(comment *models.Comment, isAuthor bool) {
+	if comment.DeletedAt.Valid {
+		comment.Deleted = true
+		comment.Body = ""
+	}
+	if comment.Hidden && !isAuthor {
+		comment.Body = ""
+	}
+	if !isAuthor {
+		comment.ReportCount = 0
+	}
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func newCommentRouter(db *gorm.DB) *gin.Engine {
+	h := NewCommentHandler(db)
+	router := gin.New()
+	router.GET("/books/:id/comments/reported", h.GetReportedComments)
+	router.GET("/episodes/:id/comments", h.GetComments)
+	router.POST("/episodes/:id/comments", h.CreateComment)
+	router.DELETE("/comments/:id", h.DeleteComment)
+	router.POST("/comments/:id/report", h.ReportComment)
+	router.PUT("/comments/:id/visibility", h.UpdateCommentVisibility)
+	return router
+}
+
+// commentPage コメント一覧のレスポンス
+type commentPage struct {
+	Items []models.Comment `json:"items"`
+	Total int64            `json:"total"`
+}
+
+// commentFixture 作者のいる公開中のBookとエピソード
+type commentFixture struct {
+	db       *gorm.DB
+	router   *gin.Engine
+	authorID uuid.UUID
+	book     models.Book
+	episode  models.Episode
+}
+
+func newCommentFixture(t *testing.T) commentFixture {
+	t.Helper()
+	db := newTestDB(t)
+	f := commentFixture{db: db, router: newCommentRouter(db), authorID: uuid.New()}
+	f.book = models.Book{ID: uuid.New(), Title: "Book", AuthorID: f.authorID, Status: models.BookStatusPublished}
+	f.episode = models.Episode{ID: uuid.New(), BookID: f.book.ID, Title: "Episode", EpisodeNo: 1, Status: models.EpisodeStatusPublished}
+	insert(t, db, &f.book, &f.episode)
+	return f
+}
+
+// post コメントを投稿し、作成されたコメントを返す
+func (f commentFixture) post(t *testing.T, episodeID, userID uuid.UUID, body string, parentID *uuid.UUID) models.Comment {
+	t.Helper()
+	rec := serve(t, f.router, http.MethodPost, "/episodes/"+episodeID.String()+"/comments",
+		commentCreateInput{Body: body, ParentID: parentID}, asUser(userID))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("post comment: status = %d, want %d (body %s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var comment models.Comment
+	decode(t, rec, &comment)
+	return comment
+}
+
+// list エピソードのコメント一覧を取得する（viewerがuuid.Nilの場合は未ログイン）
+func (f commentFixture) list(t *testing.T, viewer uuid.UUID) commentPage {
+	t.Helper()
+	var header http.Header
+	if viewer != uuid.Nil {
+		header = asUser(viewer)
+	}
+	rec := serve(t, f.router, http.MethodGet, "/episodes/"+f.episode.ID.String()+"/comments", nil, header)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list comments: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var page commentPage
+	decode(t, rec, &page)
+	return page
+}
+
+func findComment(comments []models.Comment, id uuid.UUID) *models.Comment {
+	for i := range comments {
+		if comments[i].ID == id {
+			return &comments[i]
+		}
+	}
+	return nil
+}
+
+func TestCreateCommentThreads(t *testing.T) {
+	f := newCommentFixture(t)
+	reader := uuid.New()
+
+	top := f.post(t, f.episode.ID, reader, "Great", nil)
+	reply := f.post(t, f.episode.ID, f.authorID, "Thanks", &top.ID)
+	if reply.ParentID == nil || *reply.ParentID != top.ID || !reply.IsAuthorReply {
+		t.Errorf("reply parent = %v, is_author_reply = %v, want %s and true", reply.ParentID, reply.IsAuthorReply, top.ID)
+	}
+
+	// 返信への返信はスレッドの先頭にぶら下げる
+	nested := f.post(t, f.episode.ID, reader, "You're welcome", &reply.ID)
+	if nested.ParentID == nil || *nested.ParentID != top.ID || nested.IsAuthorReply {
+		t.Errorf("nested reply parent = %v, is_author_reply = %v, want %s and false", nested.ParentID, nested.IsAuthorReply, top.ID)
+	}
+
+	page := f.list(t, uuid.Nil)
+	if page.Total != 1 || len(page.Items) != 1 || len(page.Items[0].Replies) != 2 {
+		t.Fatalf("comments = %+v, want one thread with two replies", page)
+	}
+	if page.Items[0].Replies[0].ID != reply.ID || page.Items[0].Replies[1].ID != nested.ID {
+		t.Errorf("replies are not in posting order")
+	}
+
+	// 他のエピソードのコメントには返信できない
+	other := models.Episode{ID: uuid.New(), BookID: f.book.ID, Title: "Other", EpisodeNo: 2, Status: models.EpisodeStatusPublished}
+	insert(t, f.db, &other)
+	rec := serve(t, f.router, http.MethodPost, "/episodes/"+other.ID.String()+"/comments",
+		commentCreateInput{Body: "Hi", ParentID: &top.ID}, asUser(reader))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("reply across episodes: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	rec = serve(t, f.router, http.MethodPost, "/episodes/"+f.episode.ID.String()+"/comments",
+		commentCreateInput{Body: "Hi"}, nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("without user: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestCreateCommentOnDraftEpisode(t *testing.T) {
+	f := newCommentFixture(t)
+	draft := models.Episode{ID: uuid.New(), BookID: f.book.ID, Title: "Draft", EpisodeNo: 2, Status: models.EpisodeStatusDraft}
+	insert(t, f.db, &draft)
+	path := "/episodes/" + draft.ID.String() + "/comments"
+
+	// 下書きには作者だけがコメントでき、読者からは見えない
+	rec := serve(t, f.router, http.MethodPost, path, commentCreateInput{Body: "Hi"}, asUser(uuid.New()))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("reader: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	f.post(t, draft.ID, f.authorID, "Note", nil)
+	if rec := serve(t, f.router, http.MethodGet, path, nil, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("list as reader: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := serve(t, f.router, http.MethodGet, path, nil, asUser(f.authorID)); rec.Code != http.StatusOK {
+		t.Errorf("list as author: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	f := newCommentFixture(t)
+	reader, other := uuid.New(), uuid.New()
+
+	withReplies := f.post(t, f.episode.ID, reader, "Thread", nil)
+	reply := f.post(t, f.episode.ID, other, "Reply", &withReplies.ID)
+	alone := f.post(t, f.episode.ID, other, "Alone", nil)
+
+	// 投稿者本人とBookの作者以外は削除できない
+	rec := serve(t, f.router, http.MethodDelete, "/comments/"+withReplies.ID.String(), nil, asUser(other))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("other user: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	for _, tt := range []struct {
+		id   uuid.UUID
+		user uuid.UUID
+	}{{withReplies.ID, reader}, {alone.ID, f.authorID}} {
+		if rec := serve(t, f.router, http.MethodDelete, "/comments/"+tt.id.String(), nil, asUser(tt.user)); rec.Code != http.StatusOK {
+			t.Fatalf("delete %s: status = %d, want %d (body %s)", tt.id, rec.Code, http.StatusOK, rec.Body.String())
+		}
+	}
+
+	// 返信の残っているコメントは本文を伏せて残し、返信の無いものは一覧から消える
+	page := f.list(t, uuid.Nil)
+	if page.Total != 1 || len(page.Items) != 1 {
+		t.Fatalf("comments = %+v, want only the thread with a live reply", page)
+	}
+	thread := page.Items[0]
+	if thread.ID != withReplies.ID || !thread.Deleted || thread.Body != "" {
+		t.Errorf("thread = %+v, want a deleted placeholder", thread)
+	}
+	if len(thread.Replies) != 1 || thread.Replies[0].ID != reply.ID || thread.Replies[0].Body != "Reply" {
+		t.Errorf("replies = %+v, want the live reply", thread.Replies)
+	}
+
+	// 最後の返信が削除されるとスレッドごと見えなくなる
+	if rec := serve(t, f.router, http.MethodDelete, "/comments/"+reply.ID.String(), nil, asUser(other)); rec.Code != http.StatusOK {
+		t.Fatalf("delete reply: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if page := f.list(t, uuid.Nil); page.Total != 0 || len(page.Items) != 0 {
+		t.Errorf("comments = %+v, want none", page)
+	}
+}
+
+func TestCommentVisibility(t *testing.T) {
+	f := newCommentFixture(t)
+	reader := uuid.New()
+	comment := f.post(t, f.episode.ID, reader, "Spoiler", nil)
+	path := "/comments/" + comment.ID.String() + "/visibility"
+
+	rec := serve(t, f.router, http.MethodPut, path, map[string]bool{"hidden": true}, asUser(reader))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("reader: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	rec = serve(t, f.router, http.MethodPut, path, map[string]interface{}{}, asUser(f.authorID))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("without hidden: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	rec = serve(t, f.router, http.MethodPut, path, map[string]bool{"hidden": true}, asUser(f.authorID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("hide: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	// 非表示のコメントは読者には本文を伏せ、作者には本文を見せる
+	if got := findComment(f.list(t, reader).Items, comment.ID); got == nil || !got.Hidden || got.Body != "" {
+		t.Errorf("reader sees %+v, want hidden without body", got)
+	}
+	if got := findComment(f.list(t, f.authorID).Items, comment.ID); got == nil || !got.Hidden || got.Body != "Spoiler" {
+		t.Errorf("author sees %+v, want hidden with body", got)
+	}
+
+	rec = serve(t, f.router, http.MethodPut, path, map[string]bool{"hidden": false}, asUser(f.authorID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("show: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := findComment(f.list(t, reader).Items, comment.ID); got == nil || got.Hidden || got.Body != "Spoiler" {
+		t.Errorf("reader sees %+v, want the body again", got)
+	}
+}
+
+func TestReportComments(t *testing.T) {
+	f := newCommentFixture(t)
+	reader := uuid.New()
+	mild := f.post(t, f.episode.ID, reader, "Mild", nil)
+	time.Sleep(time.Millisecond)
+	rude := f.post(t, f.episode.ID, reader, "Rude", nil)
+	f.post(t, f.episode.ID, reader, "Fine", nil)
+
+	report := func(comment models.Comment, user uuid.UUID) *httptest.ResponseRecorder {
+		return serve(t, f.router, http.MethodPost, "/comments/"+comment.ID.String()+"/report",
+			map[string]string{"reason": "spam"}, asUser(user))
+	}
+	// 同じ利用者の重複した通報は数えない
+	first := uuid.New()
+	for _, user := range []uuid.UUID{first, first, uuid.New()} {
+		if rec := report(rude, user); rec.Code != http.StatusOK {
+			t.Fatalf("report: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+		}
+	}
+	if rec := report(mild, uuid.New()); rec.Code != http.StatusOK {
+		t.Fatalf("report: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec := report(models.Comment{ID: uuid.New()}, uuid.New()); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown comment: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	// 通報数は作者にだけ見せる
+	if got := findComment(f.list(t, reader).Items, rude.ID); got == nil || got.ReportCount != 0 {
+		t.Errorf("reader sees report_count %+v, want 0", got)
+	}
+	if got := findComment(f.list(t, f.authorID).Items, rude.ID); got == nil || got.ReportCount != 2 {
+		t.Errorf("author sees report_count %+v, want 2", got)
+	}
+
+	path := "/books/" + f.book.ID.String() + "/comments/reported"
+	if rec := serve(t, f.router, http.MethodGet, path, nil, asUser(reader)); rec.Code != http.StatusForbidden {
+		t.Errorf("reported as reader: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	rec := serve(t, f.router, http.MethodGet, path, nil, asUser(f.authorID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("reported as author: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var page commentPage
+	decode(t, rec, &page)
+	if page.Total != 2 || len(page.Items) != 2 || page.Items[0].ID != rude.ID || page.Items[1].ID != mild.ID {
+		t.Errorf("reported = %+v, want rude then mild", page)
+	}
+}
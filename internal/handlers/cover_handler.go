@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"challecara2025-back/internal/media"
+	"challecara2025-back/internal/metrics"
+	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// coverThumbnailWidth 表紙のサムネイルの幅
+const coverThumbnailWidth = 320
+
+// CoverHandler Bookの表紙画像のアップロードを扱う
+type CoverHandler struct {
+	db      *gorm.DB
+	storage storage.Storage
+}
+
+func NewCoverHandler(db *gorm.DB, store storage.Storage) *CoverHandler {
+	return &CoverHandler{db: db, storage: store}
+}
+
+// UploadCover 表紙画像をアップロードし、サムネイルを生成してBookに設定
+func (h *CoverHandler) UploadCover(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	book, ok := h.findBook(c)
+	if !ok {
+		return
+	}
+
+	data, _, ok := readUpload(c, media.MaxImageSize)
+	if !ok {
+		return
+	}
+
+	img, err := media.DecodeImage(data)
+	if err != nil {
+		respondImageError(c, err)
+		return
+	}
+	thumb, err := img.Thumbnail(coverThumbnailWidth)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate thumbnail"})
+		return
+	}
+
+	// Generate UUIDv7 for the new object keys
+	objectID, err := uuid.NewV7()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate UUID"})
+		return
+	}
+	prefix := "books/" + book.ID.String() + "/cover/" + objectID.String()
+	coverKey := prefix + media.Extension(img.ContentType)
+	thumbKey := prefix + "_thumb" + media.Extension(thumb.ContentType)
+
+	ctx := c.Request.Context()
+	if err := h.storage.Put(ctx, coverKey, bytes.NewReader(img.Data), int64(len(img.Data)), img.ContentType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store cover image"})
+		return
+	}
+	if err := h.storage.Put(ctx, thumbKey, bytes.NewReader(thumb.Data), int64(len(thumb.Data)), thumb.ContentType); err != nil {
+		deleteObjects(h.storage, coverKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store thumbnail"})
+		return
+	}
+
+	oldKeys := []string{book.CoverKey, book.ThumbKey}
+	book.CoverImage = h.storage.URL(coverKey)
+	book.CoverThumb = h.storage.URL(thumbKey)
+	book.CoverKey = coverKey
+	book.ThumbKey = thumbKey
+
+	if err := db.Model(&book).Select("cover_image", "cover_thumb", "cover_key", "thumb_key").Updates(&book).Error; err != nil {
+		deleteObjects(h.storage, coverKey, thumbKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update book"})
+		return
+	}
+
+	metrics.UploadedBytes.WithLabelValues("cover").Add(float64(len(img.Data) + len(thumb.Data)))
+
+	// 差し替え前の画像は後片付けとして削除する
+	deleteObjects(h.storage, oldKeys...)
+
+	c.JSON(http.StatusOK, book)
+}
+
+// DeleteCover 表紙画像を削除
+func (h *CoverHandler) DeleteCover(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	book, ok := h.findBook(c)
+	if !ok {
+		return
+	}
+
+	oldKeys := []string{book.CoverKey, book.ThumbKey}
+	book.CoverImage = ""
+	book.CoverThumb = ""
+	book.CoverKey = ""
+	book.ThumbKey = ""
+
+	if err := db.Model(&book).Select("cover_image", "cover_thumb", "cover_key", "thumb_key").Updates(&book).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update book"})
+		return
+	}
+	deleteObjects(h.storage, oldKeys...)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cover image deleted successfully"})
+}
+
+func (h *CoverHandler) findBook(c *gin.Context) (models.Book, bool) {
+	db := h.db.WithContext(c.Request.Context())
+
+	var book models.Book
+
+	bookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return book, false
+	}
+
+	if err := db.Where("id = ?", bookID).First(&book).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+			return book, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return book, false
+	}
+
+	return book, true
+}
+
+// respondImageError 画像の検証エラーを返す
+func respondImageError(c *gin.Context, err error) {
+	if errors.Is(err, media.ErrImageTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Image is too large"})
+		return
+	}
+	c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Only JPEG, PNG and GIF images are supported"})
+}
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EngagementHandler いいね・お気に入り・作者フォローを扱う
+type EngagementHandler struct {
+	db    *gorm.DB
+	books repository.BookRepository
+}
+
+func NewEngagementHandler(db *gorm.DB, books repository.BookRepository) *EngagementHandler {
+	return &EngagementHandler{db: db, books: books}
+}
+
+// LikeBook Bookにいいねする
+func (h *EngagementHandler) LikeBook(c *gin.Context) {
+	h.setReaction(c, models.ReactionTargetBook, models.ReactionLike, true)
+}
+
+// UnlikeBook Bookのいいねを取り消す
+func (h *EngagementHandler) UnlikeBook(c *gin.Context) {
+	h.setReaction(c, models.ReactionTargetBook, models.ReactionLike, false)
+}
+
+// FavoriteBook Bookをお気に入りに登録する
+func (h *EngagementHandler) FavoriteBook(c *gin.Context) {
+	h.setReaction(c, models.ReactionTargetBook, models.ReactionFavorite, true)
+}
+
+// UnfavoriteBook Bookをお気に入りから外す
+func (h *EngagementHandler) UnfavoriteBook(c *gin.Context) {
+	h.setReaction(c, models.ReactionTargetBook, models.ReactionFavorite, false)
+}
+
+// LikeEpisode エピソードにいいねする
+func (h *EngagementHandler) LikeEpisode(c *gin.Context) {
+	h.setReaction(c, models.ReactionTargetEpisode, models.ReactionLike, true)
+}
+
+// UnlikeEpisode エピソードのいいねを取り消す
+func (h *EngagementHandler) UnlikeEpisode(c *gin.Context) {
+	h.setReaction(c, models.ReactionTargetEpisode, models.ReactionLike, false)
+}
+
+// FavoriteEpisode エピソードをお気に入りに登録する
+func (h *EngagementHandler) FavoriteEpisode(c *gin.Context) {
+	h.setReaction(c, models.ReactionTargetEpisode, models.ReactionFavorite, true)
+}
+
+// UnfavoriteEpisode エピソードをお気に入りから外す
+func (h *EngagementHandler) UnfavoriteEpisode(c *gin.Context) {
+	h.setReaction(c, models.ReactionTargetEpisode, models.ReactionFavorite, false)
+}
+
+// FollowAuthor 作者をフォローする
+func (h *EngagementHandler) FollowAuthor(c *gin.Context) {
+	h.setFollow(c, true)
+}
+
+// UnfollowAuthor 作者のフォローを解除する
+func (h *EngagementHandler) UnfollowAuthor(c *gin.Context) {
+	h.setFollow(c, false)
+}
+
+// GetAuthorStats 作者のフォロワー数を取得
+func (h *EngagementHandler) GetAuthorStats(c *gin.Context) {
+	authorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author ID"})
+		return
+	}
+
+	stats, err := h.books.AuthorStats(c.Request.Context(), authorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch author stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetFavorites 自分がお気に入りに登録したBook・エピソードを新しい順に取得（?type=book|episode）
+func (h *EngagementHandler) GetFavorites(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	targetType := c.DefaultQuery("type", models.ReactionTargetBook)
+	page, perPage := parsePagination(c)
+
+	// 非公開になった対象は一覧に含めない
+	var visibleTargets *gorm.DB
+	switch targetType {
+	case models.ReactionTargetBook:
+		visibleTargets = db.Model(&models.Book{}).Scopes(models.PublicBooks).Select("id")
+	case models.ReactionTargetEpisode:
+		visibleTargets = db.Model(&models.Episode{}).Scopes(models.PublishedEpisodes).Select("id")
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be book or episode"})
+		return
+	}
+
+	query := db.Model(&models.Reaction{}).
+		Where("user_id = ? AND kind = ?", userID, models.ReactionFavorite).
+		Where("target_type = ? AND target_id IN (?)", targetType, visibleTargets).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count favorites"})
+		return
+	}
+
+	var reactions []models.Reaction
+	if err := query.Order("created_at DESC").Offset((page - 1) * perPage).Limit(perPage).Find(&reactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch favorites"})
+		return
+	}
+
+	ids := make([]uuid.UUID, len(reactions))
+	for i, reaction := range reactions {
+		ids[i] = reaction.TargetID
+	}
+
+	var items interface{}
+	if targetType == models.ReactionTargetBook {
+		var books []models.Book
+		if len(ids) > 0 {
+			if err := db.Where("id IN ?", ids).Find(&books).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch books"})
+				return
+			}
+		}
+		episodeCounts, err := publishedEpisodeCounts(db, ids)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count episodes"})
+			return
+		}
+		booksByID := make(map[uuid.UUID]*models.Book, len(books))
+		for i := range books {
+			booksByID[books[i].ID] = &books[i]
+		}
+		// お気に入りに登録した順を保つ
+		summaries := []publicBookSummary{}
+		for _, id := range ids {
+			if book, ok := booksByID[id]; ok {
+				summaries = append(summaries, newPublicBookSummary(book, episodeCounts[id]))
+			}
+		}
+		items = summaries
+	} else {
+		var episodes []publicTOCEntry
+		if len(ids) > 0 {
+			if err := db.Model(&models.Episode{}).
+				Select("id, episode_no, title, published_at").
+				Where("id IN ?", ids).
+				Scan(&episodes).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episodes"})
+				return
+			}
+		}
+		episodesByID := make(map[uuid.UUID]publicTOCEntry, len(episodes))
+		for _, episode := range episodes {
+			episodesByID[episode.ID] = episode
+		}
+		entries := []publicTOCEntry{}
+		for _, id := range ids {
+			if episode, ok := episodesByID[id]; ok {
+				entries = append(entries, episode)
+			}
+		}
+		items = entries
+	}
+
+	c.JSON(http.StatusOK, paginatedResponse{
+		Items:   items,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+	})
+}
+
+// GetFollowing 自分がフォローしている作者の一覧を取得
+func (h *EngagementHandler) GetFollowing(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var follows []models.Follow
+	if err := db.Where("follower_id = ?", userID).Order("created_at DESC").Find(&follows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch follows"})
+		return
+	}
+
+	c.JSON(http.StatusOK, follows)
+}
+
+// setReaction いいね・お気に入りを登録または取り消し、カウンタを更新する
+func (h *EngagementHandler) setReaction(c *gin.Context, targetType, kind string, active bool) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + targetType + " ID"})
+		return
+	}
+
+	// 公開中の対象にのみリアクションできる。取り消しは非公開になった後もできるよう、対象が存在すればよい
+	var bookID uuid.UUID
+	if targetType == models.ReactionTargetBook {
+		var book models.Book
+		query := db.Where("id = ?", targetID)
+		if active {
+			query = query.Scopes(models.PublicBooks)
+		}
+		err = query.First(&book).Error
+		bookID = book.ID
+	} else {
+		var episode models.Episode
+		query := db.Where("id = ?", targetID)
+		if active {
+			query = query.Scopes(models.PublishedEpisodes).
+				Where("book_id IN (?)", db.Model(&models.Book{}).Scopes(models.PublicBooks).Select("id"))
+		}
+		err = query.First(&episode).Error
+		bookID = episode.BookID
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Target not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch target"})
+		return
+	}
+
+	column := kind + "_count"
+	var stats interface{}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		reaction := models.Reaction{UserID: userID, TargetType: targetType, TargetID: targetID, Kind: kind}
+
+		var result *gorm.DB
+		if active {
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+		} else {
+			result = tx.Where(&reaction).Delete(&models.Reaction{})
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		delta := 0
+		if result.RowsAffected > 0 {
+			delta = 1
+			if !active {
+				delta = -1
+			}
+		}
+
+		if targetType == models.ReactionTargetBook {
+			row := models.BookStats{BookID: targetID}
+			if delta != 0 {
+				if err := bumpCounter(tx, &row, column, delta); err != nil {
+					return err
+				}
+			}
+			if err := tx.Where("book_id = ?", targetID).FirstOrInit(&row).Error; err != nil {
+				return err
+			}
+			stats = row
+			return nil
+		}
+
+		row := models.EpisodeStats{EpisodeID: targetID, BookID: bookID}
+		if delta != 0 {
+			if err := bumpCounter(tx, &row, column, delta); err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("episode_id = ?", targetID).FirstOrInit(&row).Error; err != nil {
+			return err
+		}
+		stats = row
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update " + kind})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// setFollow 作者のフォローを登録または解除し、フォロワー数を更新する
+func (h *EngagementHandler) setFollow(c *gin.Context, active bool) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	authorID, err := uuid.Parse(c.Param("id"))
+	if err != nil || authorID == uuid.Nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author ID"})
+		return
+	}
+	if authorID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot follow yourself"})
+		return
+	}
+
+	var stats models.AuthorStats
+	err = db.Transaction(func(tx *gorm.DB) error {
+		follow := models.Follow{FollowerID: userID, AuthorID: authorID}
+
+		var result *gorm.DB
+		if active {
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow)
+		} else {
+			result = tx.Where(&follow).Delete(&models.Follow{})
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		stats = models.AuthorStats{AuthorID: authorID}
+		if result.RowsAffected > 0 {
+			delta := 1
+			if !active {
+				delta = -1
+			}
+			if err := bumpCounter(tx, &stats, "follower_count", delta); err != nil {
+				return err
+			}
+		}
+		return tx.Where("author_id = ?", authorID).FirstOrInit(&stats).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update follow"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// bumpCounter 集計テーブルのカウンタをdeltaだけ増減する（行が無ければ0で作成してから更新する）
+func bumpCounter(tx *gorm.DB, row interface{}, column string, delta int) error {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(row).Error; err != nil {
+		return err
+	}
+	return tx.Model(row).UpdateColumns(map[string]interface{}{
+		column:       gorm.Expr("CASE WHEN "+column+" + ? < 0 THEN 0 ELSE "+column+" + ? END", delta, delta),
+		"updated_at": time.Now(),
+	}).Error
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestReactionCanBeRemovedAfterUnpublishing(t *testing.T) {
+	db := newTestDB(t)
+	h := NewEngagementHandler(db, repository.NewBookRepository(db))
+	router := gin.New()
+	router.POST("/books/:id/favorite", h.FavoriteBook)
+	router.DELETE("/books/:id/favorite", h.UnfavoriteBook)
+	router.POST("/episodes/:id/like", h.LikeEpisode)
+	router.DELETE("/episodes/:id/like", h.UnlikeEpisode)
+
+	userID := uuid.New()
+	book := models.Book{ID: uuid.New(), Title: "Book", Status: models.BookStatusPublished}
+	episode := models.Episode{ID: uuid.New(), BookID: book.ID, Title: "Episode", EpisodeNo: 1, Status: models.EpisodeStatusPublished}
+	insert(t, db, &book, &episode)
+
+	bookPath := "/books/" + book.ID.String() + "/favorite"
+	episodePath := "/episodes/" + episode.ID.String() + "/like"
+	for _, path := range []string{bookPath, episodePath} {
+		if rec := serve(t, router, http.MethodPost, path, nil, asUser(userID)); rec.Code != http.StatusOK {
+			t.Fatalf("POST %s: status = %d, want %d (body %s)", path, rec.Code, http.StatusOK, rec.Body.String())
+		}
+	}
+
+	// 非公開にした後は登録できないが、取り消しはできる
+	if err := db.Model(&book).Update("status", models.BookStatusDraft).Error; err != nil {
+		t.Fatalf("unpublish: %v", err)
+	}
+	for _, path := range []string{bookPath, episodePath} {
+		if rec := serve(t, router, http.MethodPost, path, nil, asUser(uuid.New())); rec.Code != http.StatusNotFound {
+			t.Errorf("POST %s after unpublishing: status = %d, want %d", path, rec.Code, http.StatusNotFound)
+		}
+	}
+
+	rec := serve(t, router, http.MethodDelete, bookPath, nil, asUser(userID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unfavorite: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var bookStats models.BookStats
+	decode(t, rec, &bookStats)
+	if bookStats.FavoriteCount != 0 {
+		t.Errorf("favorite_count = %d, want 0", bookStats.FavoriteCount)
+	}
+
+	rec = serve(t, router, http.MethodDelete, episodePath, nil, asUser(userID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unlike: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var episodeStats models.EpisodeStats
+	decode(t, rec, &episodeStats)
+	if episodeStats.LikeCount != 0 {
+		t.Errorf("like_count = %d, want 0", episodeStats.LikeCount)
+	}
+
+	var remaining int64
+	db.Model(&models.Reaction{}).Where("user_id = ?", userID).Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("reactions left = %d, want 0", remaining)
+	}
+
+	// 存在しない対象は取り消しでも404
+	rec = serve(t, router, http.MethodDelete, "/books/"+uuid.NewString()+"/favorite", nil, asUser(userID))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown book: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
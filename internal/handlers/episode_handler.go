@@ -1,21 +1,26 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
+	"challecara2025-back/internal/metrics"
 	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/render"
+	"challecara2025-back/internal/repository"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
 type EpisodeHandler struct {
-	db *gorm.DB
+	episodes repository.EpisodeRepository
+	books    repository.BookRepository
 }
 
-func NewEpisodeHandler(db *gorm.DB) *EpisodeHandler {
-	return &EpisodeHandler{db: db}
+func NewEpisodeHandler(episodes repository.EpisodeRepository, books repository.BookRepository) *EpisodeHandler {
+	return &EpisodeHandler{episodes: episodes, books: books}
 }
 
 // CreateEpisode 新しいエピソードを作成
@@ -23,15 +28,14 @@ func (h *EpisodeHandler) CreateEpisode(c *gin.Context) {
 	bookID := c.Param("id")
 	var episode models.Episode
 
-	if err := c.ShouldBindJSON(&episode); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &episode) {
 		return
 	}
 
 	// book_idをパラメータから設定
 	bookUUID, err := uuid.Parse(bookID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		respondInvalidID(c, "book")
 		return
 	}
 	episode.BookID = bookUUID
@@ -39,37 +43,57 @@ func (h *EpisodeHandler) CreateEpisode(c *gin.Context) {
 	// Generate UUIDv7 for the new episode
 	newID, err := uuid.NewV7()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate UUID"})
+		respondInternalError(c, "Failed to generate UUID")
 		return
 	}
 	episode.ID = newID
 
+	// 公開ステータスを検証（未指定の場合は下書き）
+	status := episode.Status
+	if status == "" {
+		status = models.EpisodeStatusDraft
+	}
+	episode.PublishedAt = nil
+	if err := episode.ApplyStatus(status, episode.PublishAt, time.Now()); err != nil {
+		respondEpisodeStatusError(c, err)
+		return
+	}
+
 	// 資料が存在するか確認
-	var book models.Book
-	if err := h.db.Where("id = ?", bookUUID).First(&book).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+	if _, err := h.books.Get(c.Request.Context(), bookUUID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondNotFound(c, "Book not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify book"})
+		respondInternalError(c, "Failed to verify book")
 		return
 	}
 
-	if err := h.db.Create(&episode).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create episode"})
+	if !h.checkImageReferences(c, &episode) {
 		return
 	}
 
+	if err := h.episodes.Create(c.Request.Context(), &episode); err != nil {
+		respondInternalError(c, "Failed to create episode")
+		return
+	}
+	metrics.EpisodesCreated.Inc()
+
 	c.JSON(http.StatusCreated, episode)
 }
 
 // GetEpisodes 特定の資料のすべてのエピソードを取得
 func (h *EpisodeHandler) GetEpisodes(c *gin.Context) {
-	bookID := c.Param("id")
-	var episodes []models.Episode
+	bookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondInvalidID(c, "book")
+		return
+	}
 
-	if err := h.db.Where("book_id = ?", bookID).Order("episode_no").Find(&episodes).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episodes"})
+	// ?status=published のように公開ステータスで絞り込み
+	episodes, err := h.episodes.ListByBook(c.Request.Context(), bookID, c.Query("status"))
+	if err != nil {
+		respondInternalError(c, "Failed to fetch episodes")
 		return
 	}
 
@@ -79,20 +103,20 @@ func (h *EpisodeHandler) GetEpisodes(c *gin.Context) {
 // GetEpisode 特定のエピソードを取得
 func (h *EpisodeHandler) GetEpisode(c *gin.Context) {
 	id := c.Param("id")
-	var episode models.Episode
 
 	episodeID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
+		respondInvalidID(c, "episode")
 		return
 	}
 
-	if err := h.db.Where("id = ?", episodeID).First(&episode).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+	episode, err := h.episodes.Get(c.Request.Context(), episodeID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondNotFound(c, "Episode not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episode"})
+		respondInternalError(c, "Failed to fetch episode")
 		return
 	}
 
@@ -102,30 +126,45 @@ func (h *EpisodeHandler) GetEpisode(c *gin.Context) {
 // UpdateEpisode エピソードを更新
 func (h *EpisodeHandler) UpdateEpisode(c *gin.Context) {
 	id := c.Param("id")
-	var episode models.Episode
 
 	episodeID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
+		respondInvalidID(c, "episode")
 		return
 	}
 
-	if err := h.db.Where("id = ?", episodeID).First(&episode).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+	episode, err := h.episodes.Get(c.Request.Context(), episodeID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondNotFound(c, "Episode not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episode"})
+		respondInternalError(c, "Failed to fetch episode")
 		return
 	}
 
-	if err := c.ShouldBindJSON(&episode); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// 公開日時はApplyStatus経由でのみ変更し、IDと所属するBookは変更しない
+	current := *episode
+	if !bindJSON(c, episode) {
+		return
+	}
+	episode.ID = current.ID
+	episode.BookID = current.BookID
+	episode.PublishedAt = current.PublishedAt
+	if episode.Status == "" {
+		episode.Status = current.Status
+	}
+	if err := episode.ApplyStatus(episode.Status, episode.PublishAt, time.Now()); err != nil {
+		respondEpisodeStatusError(c, err)
+		return
+	}
+
+	if !h.checkImageReferences(c, episode) {
 		return
 	}
 
-	if err := h.db.Save(&episode).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update episode"})
+	if err := h.episodes.Update(c.Request.Context(), episode); err != nil {
+		respondInternalError(c, "Failed to update episode")
 		return
 	}
 
@@ -138,12 +177,12 @@ func (h *EpisodeHandler) DeleteEpisode(c *gin.Context) {
 
 	episodeID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
+		respondInvalidID(c, "episode")
 		return
 	}
 
-	if err := h.db.Where("id = ?", episodeID).Delete(&models.Episode{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete episode"})
+	if err := h.episodes.Delete(c.Request.Context(), episodeID); err != nil {
+		respondInternalError(c, "Failed to delete episode")
 		return
 	}
 
@@ -157,28 +196,54 @@ func (h *EpisodeHandler) GetEpisodesByIDs(c *gin.Context) {
 	var input struct {
 		IDs []uuid.UUID `json:"ids" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &input) {
 		return
 	}
 
-	var episodes []models.Episode
-	query := h.db.Where("id IN ?", input.IDs)
-
 	// book_idがパスに含まれている場合はフィルタリング
+	var bookID *uuid.UUID
 	if bookIDParam != "" {
-		bookID, err := uuid.Parse(bookIDParam)
+		parsed, err := uuid.Parse(bookIDParam)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+			respondInvalidID(c, "book")
 			return
 		}
-		query = query.Where("book_id = ?", bookID)
+		bookID = &parsed
 	}
 
-	if err := query.Find(&episodes).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episodes"})
+	episodes, err := h.episodes.FindByIDs(c.Request.Context(), input.IDs, bookID)
+	if err != nil {
+		respondInternalError(c, "Failed to fetch episodes")
 		return
 	}
 
 	c.JSON(http.StatusOK, episodes)
 }
+
+// checkImageReferences 本文中の挿絵が同じBookの画像かを検証し、失敗時はレスポンスを書き込む
+func (h *EpisodeHandler) checkImageReferences(c *gin.Context, episode *models.Episode) bool {
+	images, err := h.books.Images(c.Request.Context(), episode.BookID, render.ImageIDs(episode.Content))
+	if err != nil {
+		respondInternalError(c, "Failed to verify images")
+		return false
+	}
+	invalid := invalidImageReferences(episode.Content, images)
+	if len(invalid) > 0 {
+		respondProblem(c, NewProblem(http.StatusUnprocessableEntity, CodeInvalidImageReference,
+			"Content references images that do not belong to this book").
+			With("image_ids", invalid))
+		return false
+	}
+	return true
+}
+
+// respondEpisodeStatusError 公開ステータスの検証エラーを422で返す
+func respondEpisodeStatusError(c *gin.Context, err error) {
+	if errors.Is(err, models.ErrPublishAtRequired) {
+		problem := NewProblem(http.StatusUnprocessableEntity, CodePublishAtRequired, "publish_at is required for scheduled episodes")
+		problem.Errors = []FieldError{{Field: "publish_at", Code: "required", Message: "is required for scheduled episodes"}}
+		respondProblem(c, problem)
+		return
+	}
+	respondProblem(c, NewProblem(http.StatusUnprocessableEntity, CodeInvalidStatus, "Invalid episode status"))
+}
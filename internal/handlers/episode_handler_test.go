@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func newEpisodeRouter(episodes repository.EpisodeRepository, books repository.BookRepository) *gin.Engine {
+	h := NewEpisodeHandler(episodes, books)
+	router := gin.New()
+	router.POST("/books/:id/episodes", h.CreateEpisode)
+	router.GET("/books/:id/episodes", h.GetEpisodes)
+	router.POST("/books/:id/episodes/batch", h.GetEpisodesByIDs)
+	router.GET("/episodes/:id", h.GetEpisode)
+	router.PUT("/episodes/:id", h.UpdateEpisode)
+	router.DELETE("/episodes/:id", h.DeleteEpisode)
+	return router
+}
+
+// putEpisode リポジトリにエピソードを直接登録する
+func putEpisode(t *testing.T, episodes repository.EpisodeRepository, episode models.Episode) models.Episode {
+	t.Helper()
+	if episode.ID == uuid.Nil {
+		episode.ID = uuid.New()
+	}
+	if episode.Status == "" {
+		episode.Status = models.EpisodeStatusDraft
+	}
+	if err := episodes.Create(context.Background(), &episode); err != nil {
+		t.Fatalf("create episode: %v", err)
+	}
+	return episode
+}
+
+func TestCreateEpisode(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	episodes := repository.NewMemoryEpisodeRepository()
+	router := newEpisodeRouter(episodes, books)
+	book := putBook(t, books, models.Book{Title: "Book"})
+
+	rec := serve(t, router, http.MethodPost, "/books/"+book.ID.String()+"/episodes", map[string]interface{}{
+		"title":      "Episode 1",
+		"content":    "Once upon a time",
+		"episode_no": 1,
+	}, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+
+	var created models.Episode
+	decode(t, rec, &created)
+	if created.BookID != book.ID {
+		t.Errorf("book_id = %s, want %s", created.BookID, book.ID)
+	}
+	if created.Status != models.EpisodeStatusDraft {
+		t.Errorf("status = %q, want %q", created.Status, models.EpisodeStatusDraft)
+	}
+	if _, err := episodes.Get(context.Background(), created.ID); err != nil {
+		t.Errorf("episode was not stored: %v", err)
+	}
+}
+
+func TestCreateEpisodeBookNotFound(t *testing.T) {
+	router := newEpisodeRouter(repository.NewMemoryEpisodeRepository(), repository.NewMemoryBookRepository())
+
+	rec := serve(t, router, http.MethodPost, "/books/"+uuid.NewString()+"/episodes", map[string]interface{}{
+		"title": "Episode 1",
+	}, nil)
+	expectProblem(t, rec, http.StatusNotFound, CodeNotFound)
+}
+
+func TestCreateEpisodeStatus(t *testing.T) {
+	publishAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "scheduled",
+			body:       map[string]interface{}{"status": models.EpisodeStatusScheduled, "publish_at": publishAt},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "scheduled without publish_at",
+			body:       map[string]interface{}{"status": models.EpisodeStatusScheduled},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   CodePublishAtRequired,
+		},
+		{
+			name:       "unknown status",
+			body:       map[string]interface{}{"status": "archived"},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   CodeInvalidStatus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			books := repository.NewMemoryBookRepository()
+			router := newEpisodeRouter(repository.NewMemoryEpisodeRepository(), books)
+			book := putBook(t, books, models.Book{Title: "Book"})
+
+			tt.body["title"] = "Episode"
+			rec := serve(t, router, http.MethodPost, "/books/"+book.ID.String()+"/episodes", tt.body, nil)
+
+			if tt.wantCode != "" {
+				expectProblem(t, rec, tt.wantStatus, tt.wantCode)
+				return
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			var created models.Episode
+			decode(t, rec, &created)
+			if created.PublishAt == nil || !created.PublishAt.Equal(publishAt) {
+				t.Errorf("publish_at = %v, want %v", created.PublishAt, publishAt)
+			}
+		})
+	}
+}
+
+func TestCreateEpisodeImageReferences(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	router := newEpisodeRouter(repository.NewMemoryEpisodeRepository(), books)
+	book := putBook(t, books, models.Book{Title: "Book"})
+	other := putBook(t, books, models.Book{Title: "Other"})
+
+	own := models.BookImage{ID: uuid.New(), BookID: book.ID}
+	foreign := models.BookImage{ID: uuid.New(), BookID: other.ID}
+	books.PutImage(own)
+	books.PutImage(foreign)
+
+	rec := serve(t, router, http.MethodPost, "/books/"+book.ID.String()+"/episodes", map[string]interface{}{
+		"title":   "Episode",
+		"content": "[[image:" + own.ID.String() + "]]",
+	}, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("own image: status = %d, want %d (body %s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+
+	// 他のBookの挿絵は参照できない
+	rec = serve(t, router, http.MethodPost, "/books/"+book.ID.String()+"/episodes", map[string]interface{}{
+		"title":   "Episode",
+		"content": "[[image:" + own.ID.String() + "]]\n[[image:" + foreign.ID.String() + "]]",
+	}, nil)
+	problem := expectProblem(t, rec, http.StatusUnprocessableEntity, CodeInvalidImageReference)
+	if len(problem.ImageIDs) != 1 || problem.ImageIDs[0] != foreign.ID.String() {
+		t.Errorf("image_ids = %v, want [%s]", problem.ImageIDs, foreign.ID)
+	}
+}
+
+func TestGetEpisodesFiltersByStatus(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	episodes := repository.NewMemoryEpisodeRepository()
+	router := newEpisodeRouter(episodes, books)
+	book := putBook(t, books, models.Book{Title: "Book"})
+
+	putEpisode(t, episodes, models.Episode{BookID: book.ID, EpisodeNo: 2, Status: models.EpisodeStatusPublished})
+	putEpisode(t, episodes, models.Episode{BookID: book.ID, EpisodeNo: 1, Status: models.EpisodeStatusPublished})
+	putEpisode(t, episodes, models.Episode{BookID: book.ID, EpisodeNo: 3})
+	putEpisode(t, episodes, models.Episode{BookID: uuid.New(), EpisodeNo: 1, Status: models.EpisodeStatusPublished})
+
+	rec := serve(t, router, http.MethodGet, "/books/"+book.ID.String()+"/episodes?status=published", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var got []models.Episode
+	decode(t, rec, &got)
+	if len(got) != 2 || got[0].EpisodeNo != 1 || got[1].EpisodeNo != 2 {
+		t.Errorf("episodes = %+v, want published episodes 1 and 2 in order", got)
+	}
+}
+
+func TestUpdateEpisodeKeepsPublishedAt(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	episodes := repository.NewMemoryEpisodeRepository()
+	router := newEpisodeRouter(episodes, books)
+	book := putBook(t, books, models.Book{Title: "Book"})
+
+	publishedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	episode := putEpisode(t, episodes, models.Episode{
+		BookID: book.ID, Title: "Episode", EpisodeNo: 1,
+		Status: models.EpisodeStatusPublished, PublishedAt: &publishedAt,
+	})
+
+	rec := serve(t, router, http.MethodPut, "/episodes/"+episode.ID.String(), map[string]interface{}{
+		"title":        "Renamed",
+		"published_at": time.Now(),
+	}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	stored, _ := episodes.Get(context.Background(), episode.ID)
+	if stored.Title != "Renamed" {
+		t.Errorf("title = %q, want %q", stored.Title, "Renamed")
+	}
+	if stored.Status != models.EpisodeStatusPublished {
+		t.Errorf("status = %q, want %q", stored.Status, models.EpisodeStatusPublished)
+	}
+	if stored.PublishedAt == nil || !stored.PublishedAt.Equal(publishedAt) {
+		t.Errorf("published_at = %v, want %v", stored.PublishedAt, publishedAt)
+	}
+}
+
+func TestUpdateEpisodeKeepsBook(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	episodes := repository.NewMemoryEpisodeRepository()
+	router := newEpisodeRouter(episodes, books)
+	book := putBook(t, books, models.Book{Title: "Book"})
+	other := putBook(t, books, models.Book{Title: "Other"})
+	foreign := models.BookImage{ID: uuid.New(), BookID: other.ID}
+	books.PutImage(foreign)
+	episode := putEpisode(t, episodes, models.Episode{BookID: book.ID, Title: "Episode", EpisodeNo: 1})
+
+	// 他のBookに移すことはできない
+	rec := serve(t, router, http.MethodPut, "/episodes/"+episode.ID.String(), map[string]interface{}{
+		"id":      uuid.New(),
+		"book_id": other.ID,
+		"title":   "Renamed",
+	}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	stored, err := episodes.Get(context.Background(), episode.ID)
+	if err != nil {
+		t.Fatalf("episode was not kept: %v", err)
+	}
+	if stored.BookID != book.ID || stored.Title != "Renamed" {
+		t.Errorf("book_id = %s, title = %q, want %s and %q", stored.BookID, stored.Title, book.ID, "Renamed")
+	}
+
+	// book_idを指定しても、挿絵は元のBookのものかで検証する
+	rec = serve(t, router, http.MethodPut, "/episodes/"+episode.ID.String(), map[string]interface{}{
+		"book_id": other.ID,
+		"title":   "Episode",
+		"content": "[[image:" + foreign.ID.String() + "]]",
+	}, nil)
+	expectProblem(t, rec, http.StatusUnprocessableEntity, CodeInvalidImageReference)
+}
+
+func TestGetEpisodesByIDs(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	episodes := repository.NewMemoryEpisodeRepository()
+	router := newEpisodeRouter(episodes, books)
+	book := putBook(t, books, models.Book{Title: "Book"})
+
+	own := putEpisode(t, episodes, models.Episode{BookID: book.ID, EpisodeNo: 1})
+	other := putEpisode(t, episodes, models.Episode{BookID: uuid.New(), EpisodeNo: 1})
+
+	rec := serve(t, router, http.MethodPost, "/books/"+book.ID.String()+"/episodes/batch",
+		map[string]interface{}{"ids": []uuid.UUID{own.ID, other.ID}}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var got []models.Episode
+	decode(t, rec, &got)
+	if len(got) != 1 || got[0].ID != own.ID {
+		t.Errorf("episodes = %+v, want only %s", got, own.ID)
+	}
+}
+
+func TestGetEpisodeErrors(t *testing.T) {
+	router := newEpisodeRouter(repository.NewMemoryEpisodeRepository(), repository.NewMemoryBookRepository())
+
+	rec := serve(t, router, http.MethodGet, "/episodes/not-a-uuid", nil, nil)
+	expectProblem(t, rec, http.StatusBadRequest, CodeInvalidID)
+
+	rec = serve(t, router, http.MethodGet, "/episodes/"+uuid.NewString(), nil, nil)
+	expectProblem(t, rec, http.StatusNotFound, CodeNotFound)
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+
+	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/render"
+	"challecara2025-back/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExportHandler エピソードのHTML表示とBookのEPUB書き出しを扱う
+type ExportHandler struct {
+	db      *gorm.DB
+	storage storage.Storage
+}
+
+func NewExportHandler(db *gorm.DB, store storage.Storage) *ExportHandler {
+	return &ExportHandler{db: db, storage: store}
+}
+
+// GetEpisodeHTML エピソード本文をHTMLに変換して取得（下書きも対象）
+func (h *ExportHandler) GetEpisodeHTML(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	episodeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
+		return
+	}
+
+	var episode models.Episode
+	if err := db.Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episode"})
+		return
+	}
+
+	content, err := renderEpisodeHTML(db, &episode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render episode"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":         episode.ID,
+		"book_id":    episode.BookID,
+		"episode_no": episode.EpisodeNo,
+		"title":      episode.Title,
+		"html":       content,
+	})
+}
+
+// ExportEPUB 公開済みのエピソードをまとめてEPUBとして書き出す
+func (h *ExportHandler) ExportEPUB(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	bookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+
+	var book models.Book
+	if err := db.Where("id = ?", bookID).First(&book).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return
+	}
+
+	var episodes []models.Episode
+	if err := db.Scopes(models.PublishedEpisodes).
+		Where("book_id = ?", book.ID).
+		Order("episode_no").
+		Find(&episodes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episodes"})
+		return
+	}
+
+	epub := render.Book{
+		ID:          book.ID,
+		Title:       book.Title,
+		Description: book.Description,
+		Modified:    book.UpdatedAt,
+	}
+
+	var imageIDs []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, episode := range episodes {
+		epub.Chapters = append(epub.Chapters, render.Chapter{Title: episode.Title, Content: episode.Content})
+		if episode.UpdatedAt.After(epub.Modified) {
+			epub.Modified = episode.UpdatedAt
+		}
+		for _, id := range render.ImageIDs(episode.Content) {
+			if !seen[id] {
+				seen[id] = true
+				imageIDs = append(imageIDs, id)
+			}
+		}
+	}
+
+	if len(imageIDs) > 0 {
+		var images []models.BookImage
+		if err := db.Where("book_id = ? AND id IN ?", book.ID, imageIDs).Find(&images).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch images"})
+			return
+		}
+		for _, image := range images {
+			data, err := h.readObject(c, image.StorageKey)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read image"})
+				return
+			}
+			epub.Images = append(epub.Images, render.Image{ID: image.ID, ContentType: image.ContentType, Data: data})
+		}
+	}
+
+	// 表紙は取得できなくても書き出しは続ける
+	if book.CoverKey != "" {
+		if data, err := h.readObject(c, book.CoverKey); err == nil {
+			epub.Cover = &render.Image{ID: book.ID, ContentType: http.DetectContentType(data), Data: data}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := render.WriteEPUB(&buf, &epub); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate EPUB"})
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": sanitizeFileName(book.Title) + ".epub"}))
+	c.Data(http.StatusOK, "application/epub+zip", buf.Bytes())
+}
+
+func (h *ExportHandler) readObject(c *gin.Context, key string) ([]byte, error) {
+	reader, err := h.storage.Get(c.Request.Context(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"challecara2025-back/internal/database"
+	"challecara2025-back/internal/migrations"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestDB マイグレーションを適用したメモリ上のSQLiteを開く（gorm.DBを直接使うハンドラー用）
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := migrations.New(db).Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// insert テスト用の行を作成する
+func insert(t *testing.T, db *gorm.DB, rows ...interface{}) {
+	t.Helper()
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("insert %T: %v", row, err)
+		}
+	}
+}
+
+// asUser X-User-IDヘッダーを返す
+func asUser(id fmt.Stringer) http.Header {
+	header := http.Header{}
+	header.Set(UserIDHeader, id.String())
+	return header
+}
+
+// serve リクエストをルーターで処理し、レスポンスを返す（bodyがnilでなければJSONで送る）
+func serve(t *testing.T, router http.Handler, method, path string, body interface{}, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader *bytes.Reader
+	switch b := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case string:
+		reader = bytes.NewReader([]byte(b))
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("marshal request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode レスポンスの本文をvに読み込む
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+}
+
+// problemResponse 問題詳細のうちテストで確認するメンバー
+type problemResponse struct {
+	Status  int          `json:"status"`
+	Code    string       `json:"code"`
+	Errors  []FieldError `json:"errors"`
+	Allowed []string     `json:"allowed"`
+	// ImageIDs 不正な挿絵の参照（invalid_image_reference）
+	ImageIDs []string `json:"image_ids"`
+}
+
+// expectProblem レスポンスが指定したステータス・コードの問題詳細であることを確認する
+func expectProblem(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) problemResponse {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, status, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != ProblemContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ProblemContentType)
+	}
+	var problem problemResponse
+	decode(t, rec, &problem)
+	if problem.Code != code {
+		t.Errorf("code = %q, want %q", problem.Code, code)
+	}
+	if problem.Status != status {
+		t.Errorf("problem status = %d, want %d", problem.Status, status)
+	}
+	return problem
+}
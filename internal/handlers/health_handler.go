@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"challecara2025-back/internal/database"
+	"challecara2025-back/internal/migrations"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// readinessTimeout 準備状況の確認1回あたりの待ち時間の上限
+const readinessTimeout = 2 * time.Second
+
+// HealthHandler コンテナのオーケストレーター向けの死活・準備状況の確認を扱う
+type HealthHandler struct {
+	db       *gorm.DB
+	migrator *migrations.Migrator
+}
+
+func NewHealthHandler(db *gorm.DB) *HealthHandler {
+	return &HealthHandler{db: db, migrator: migrations.New(db)}
+}
+
+// componentStatus 依存先ごとの確認結果。
+// 認証なしで公開するため、エラーの詳細は返さずにログにだけ出力する
+type componentStatus struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	// Pool 接続プールの状態（databaseのみ）
+	Pool *database.PoolStats `json:"pool,omitempty"`
+	// Pending 未適用のマイグレーション（migrationsのみ）
+	Pending []string `json:"pending,omitempty"`
+}
+
+// Livez プロセスが応答できるかを返す（依存先は確認しない）
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz データベースへの接続とマイグレーションの適用状況を確認し、リクエストを受けられるかを返す
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	components := gin.H{}
+	ready := true
+
+	dbStatus := h.checkDatabase(ctx)
+	components["database"] = dbStatus
+	if dbStatus.Status != "ok" {
+		ready = false
+	} else {
+		// データベースに接続できない場合はマイグレーションの状況も確認できない
+		migrationStatus := h.checkMigrations(ctx)
+		components["migrations"] = migrationStatus
+		if migrationStatus.Status != "ok" {
+			ready = false
+		}
+	}
+
+	status, code := "ok", http.StatusOK
+	if !ready {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{"status": status, "components": components})
+}
+
+func (h *HealthHandler) checkDatabase(ctx context.Context) componentStatus {
+	started := time.Now()
+	sqlDB, err := h.db.DB()
+	if err == nil {
+		err = sqlDB.PingContext(ctx)
+	}
+	result := componentStatus{Status: "ok", LatencyMS: time.Since(started).Milliseconds()}
+	if err != nil {
+		slog.ErrorContext(ctx, "Readiness check failed", "component", "database", "error", err)
+		result.Status = "error"
+	}
+	if stats, err := database.Stats(h.db); err == nil {
+		result.Pool = &stats
+	}
+	return result
+}
+
+func (h *HealthHandler) checkMigrations(ctx context.Context) componentStatus {
+	started := time.Now()
+	pending, err := h.migrator.Pending(ctx)
+	result := componentStatus{Status: "ok", LatencyMS: time.Since(started).Milliseconds()}
+	switch {
+	case err != nil:
+		slog.ErrorContext(ctx, "Readiness check failed", "component", "migrations", "error", err)
+		result.Status = "error"
+	case len(pending) > 0:
+		result.Status = "pending"
+		for _, migration := range pending {
+			result.Pending = append(result.Pending, fmt.Sprintf("%d_%s", migration.Version, migration.Name))
+		}
+	}
+	return result
+}
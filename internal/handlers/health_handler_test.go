@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadyz(t *testing.T) {
+	db := newTestDB(t)
+	router := gin.New()
+	router.GET("/readyz", NewHealthHandler(db).Readyz)
+
+	rec := serve(t, router, http.MethodGet, "/readyz", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	// 接続できない場合も、ドライバーのエラーメッセージは返さない
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	sqlDB.Close()
+	rec = serve(t, router, http.MethodGet, "/readyz", nil, nil)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusServiceUnavailable, rec.Body.String())
+	}
+	var body struct {
+		Status     string                            `json:"status"`
+		Components map[string]map[string]interface{} `json:"components"`
+	}
+	decode(t, rec, &body)
+	database := body.Components["database"]
+	if body.Status != "unavailable" || database["status"] != "error" {
+		t.Errorf("body = %+v, want an unavailable database", body)
+	}
+	if _, ok := database["error"]; ok || strings.Contains(rec.Body.String(), "database is closed") {
+		t.Errorf("body = %s, want no error details", rec.Body.String())
+	}
+}
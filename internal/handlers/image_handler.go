@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+
+	"challecara2025-back/internal/media"
+	"challecara2025-back/internal/metrics"
+	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/render"
+	"challecara2025-back/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ImageHandler エピソード本文に埋め込む挿絵を扱う
+type ImageHandler struct {
+	db      *gorm.DB
+	storage storage.Storage
+}
+
+func NewImageHandler(db *gorm.DB, store storage.Storage) *ImageHandler {
+	return &ImageHandler{db: db, storage: store}
+}
+
+// UploadImage Bookに挿絵をアップロード
+func (h *ImageHandler) UploadImage(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	bookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+
+	if err := db.Where("id = ?", bookID).First(&models.Book{}).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return
+	}
+
+	data, _, ok := readUpload(c, media.MaxImageSize)
+	if !ok {
+		return
+	}
+
+	img, err := media.DecodeImage(data)
+	if err != nil {
+		respondImageError(c, err)
+		return
+	}
+
+	// Generate UUIDv7 for the new image
+	newID, err := uuid.NewV7()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate UUID"})
+		return
+	}
+
+	image := models.BookImage{
+		ID:          newID,
+		BookID:      bookID,
+		ContentType: img.ContentType,
+		Width:       img.Width,
+		Height:      img.Height,
+		Size:        int64(len(img.Data)),
+		StorageKey:  "books/" + bookID.String() + "/images/" + newID.String() + media.Extension(img.ContentType),
+	}
+	image.URL = h.storage.URL(image.StorageKey)
+
+	if err := h.storage.Put(c.Request.Context(), image.StorageKey, bytes.NewReader(img.Data), image.Size, image.ContentType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store image"})
+		return
+	}
+
+	if err := db.Create(&image).Error; err != nil {
+		deleteObjects(h.storage, image.StorageKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create image"})
+		return
+	}
+	metrics.UploadedBytes.WithLabelValues("image").Add(float64(image.Size))
+
+	image.Token = render.ImageToken(image.ID)
+	c.JSON(http.StatusCreated, image)
+}
+
+// GetImages Bookの挿絵一覧を取得
+func (h *ImageHandler) GetImages(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	bookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+
+	var images []models.BookImage
+	if err := db.Where("book_id = ?", bookID).Order("created_at").Find(&images).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch images"})
+		return
+	}
+	for i := range images {
+		images[i].Token = render.ImageToken(images[i].ID)
+	}
+
+	c.JSON(http.StatusOK, images)
+}
+
+// DeleteImage 挿絵を削除（本文から参照されている場合は削除しない）
+func (h *ImageHandler) DeleteImage(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	imageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		return
+	}
+
+	var image models.BookImage
+	if err := db.Where("id = ?", imageID).First(&image).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch image"})
+		return
+	}
+
+	var referencing []models.Episode
+	if err := db.Select("id", "episode_no", "title").
+		Where("book_id = ? AND content LIKE ?", image.BookID, "%"+render.ImageToken(image.ID)+"%").
+		Order("episode_no").
+		Find(&referencing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check image references"})
+		return
+	}
+	if len(referencing) > 0 {
+		episodeIDs := make([]uuid.UUID, len(referencing))
+		for i, episode := range referencing {
+			episodeIDs[i] = episode.ID
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Image is referenced by episodes", "episode_ids": episodeIDs})
+		return
+	}
+
+	if err := db.Delete(&image).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete image"})
+		return
+	}
+	deleteObjects(h.storage, image.StorageKey)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
+}
+
+// findReferencedImages 本文が参照している挿絵のうちBookに属するものを取得する
+func findReferencedImages(db *gorm.DB, bookID uuid.UUID, content string) ([]models.BookImage, error) {
+	var images []models.BookImage
+	ids := render.ImageIDs(content)
+	if len(ids) == 0 {
+		return images, nil
+	}
+	err := db.Where("book_id = ? AND id IN ?", bookID, ids).Find(&images).Error
+	return images, err
+}
+
+// invalidImageReferences 本文が参照している挿絵のうち、imagesに含まれないものを返す
+func invalidImageReferences(content string, images []models.BookImage) []uuid.UUID {
+	found := make(map[uuid.UUID]bool, len(images))
+	for _, image := range images {
+		found[image.ID] = true
+	}
+
+	var invalid []uuid.UUID
+	for _, id := range render.ImageIDs(content) {
+		if !found[id] {
+			invalid = append(invalid, id)
+		}
+	}
+	return invalid
+}
+
+// renderEpisodeHTML エピソード本文をHTMLに変換し、挿絵を公開URLに解決する
+func renderEpisodeHTML(db *gorm.DB, episode *models.Episode) (string, error) {
+	images, err := findReferencedImages(db, episode.BookID, episode.Content)
+	if err != nil {
+		return "", err
+	}
+	urls := make(map[uuid.UUID]string, len(images))
+	for _, image := range images {
+		urls[image.ID] = image.URL
+	}
+
+	return render.HTML(episode.Content, func(id uuid.UUID) (string, bool) {
+		url, ok := urls[id]
+		return url, ok
+	}), nil
+}
@@ -1,21 +1,23 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/repository"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
 type MaterialHandler struct {
-	db *gorm.DB
+	materials repository.MaterialRepository
+	books     repository.BookRepository
 }
 
-func NewMaterialHandler(db *gorm.DB) *MaterialHandler {
-	return &MaterialHandler{db: db}
+func NewMaterialHandler(materials repository.MaterialRepository, books repository.BookRepository) *MaterialHandler {
+	return &MaterialHandler{materials: materials, books: books}
 }
 
 type materialCreateInput struct {
@@ -28,48 +30,50 @@ type materialUpdateInput struct {
 	Content string `json:"content" binding:"required"`
 }
 
+type materialAttachInput struct {
+	MaterialID uuid.UUID `json:"material_id" binding:"required"`
+}
+
 // CreateMaterial 新しい参考資料を作成
 func (h *MaterialHandler) CreateMaterial(c *gin.Context) {
 	bookIDParam := c.Param("id")
 	bookUUID, err := uuid.Parse(bookIDParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		respondInvalidID(c, "book")
 		return
 	}
 
 	var input materialCreateInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &input) {
 		return
 	}
 
 	// 資料が紐づくBookの存在確認
-	var book models.Book
-	if err := h.db.Where("id = ?", bookUUID).First(&book).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+	if _, err := h.books.Get(c.Request.Context(), bookUUID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondNotFound(c, "Book not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify book"})
+		respondInternalError(c, "Failed to verify book")
 		return
 	}
 
 	// Generate UUIDv7 for the new material
 	newID, err := uuid.NewV7()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate UUID"})
+		respondInternalError(c, "Failed to generate UUID")
 		return
 	}
 
 	material := models.Material{
 		ID:      newID,
-		BookID:  bookUUID,
+		BookID:  &bookUUID,
 		Title:   input.Title,
 		Content: input.Content,
 	}
 
-	if err := h.db.Create(&material).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create material"})
+	if err := h.materials.Create(c.Request.Context(), &material); err != nil {
+		respondInternalError(c, "Failed to create material")
 		return
 	}
 
@@ -82,22 +86,24 @@ func (h *MaterialHandler) GetMaterials(c *gin.Context) {
 
 	bookUUID, err := uuid.Parse(bookIDParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		respondInvalidID(c, "book")
 		return
 	}
 
-	if err := h.db.Where("id = ?", bookUUID).First(&models.Book{}).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+	book, err := h.books.Get(c.Request.Context(), bookUUID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondNotFound(c, "Book not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify book"})
+		respondInternalError(c, "Failed to verify book")
 		return
 	}
 
-	var materials []models.Material
-	if err := h.db.Where("book_id = ?", bookIDParam).Order("created_at DESC").Find(&materials).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch materials"})
+	// Book固有 + 共有されたもの + シリーズ共有 + 作者ライブラリ
+	materials, err := h.materials.ListVisible(c.Request.Context(), book)
+	if err != nil {
+		respondInternalError(c, "Failed to fetch materials")
 		return
 	}
 
@@ -111,26 +117,33 @@ func (h *MaterialHandler) GetMaterialsByIDs(c *gin.Context) {
 	var input struct {
 		IDs []uuid.UUID `json:"ids" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &input) {
 		return
 	}
 
-	var materials []models.Material
-	query := h.db.Where("id IN ?", input.IDs)
-
-	// book_idがパスに含まれている場合はフィルタリング
+	// book_idがパスに含まれている場合はそのBookから参照できる資料に絞り込む
+	var book *models.Book
 	if bookIDParam != "" {
 		bookID, err := uuid.Parse(bookIDParam)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+			respondInvalidID(c, "book")
+			return
+		}
+
+		book, err = h.books.Get(c.Request.Context(), bookID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				respondNotFound(c, "Book not found")
+				return
+			}
+			respondInternalError(c, "Failed to verify book")
 			return
 		}
-		query = query.Where("book_id = ?", bookID)
 	}
 
-	if err := query.Find(&materials).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch materials"})
+	materials, err := h.materials.FindByIDs(c.Request.Context(), input.IDs, book)
+	if err != nil {
+		respondInternalError(c, "Failed to fetch materials")
 		return
 	}
 
@@ -140,20 +153,20 @@ func (h *MaterialHandler) GetMaterialsByIDs(c *gin.Context) {
 // GetMaterial 特定の参考資料を取得
 func (h *MaterialHandler) GetMaterial(c *gin.Context) {
 	id := c.Param("id")
-	var material models.Material
 
 	materialID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+		respondInvalidID(c, "material")
 		return
 	}
 
-	if err := h.db.Where("id = ?", materialID).First(&material).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Material not found"})
+	material, err := h.materials.Get(c.Request.Context(), materialID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondNotFound(c, "Material not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch material"})
+		respondInternalError(c, "Failed to fetch material")
 		return
 	}
 
@@ -163,34 +176,33 @@ func (h *MaterialHandler) GetMaterial(c *gin.Context) {
 // UpdateMaterial 参考資料を更新
 func (h *MaterialHandler) UpdateMaterial(c *gin.Context) {
 	id := c.Param("id")
-	var material models.Material
 
 	materialID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+		respondInvalidID(c, "material")
 		return
 	}
 
-	if err := h.db.Where("id = ?", materialID).First(&material).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Material not found"})
+	material, err := h.materials.Get(c.Request.Context(), materialID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondNotFound(c, "Material not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch material"})
+		respondInternalError(c, "Failed to fetch material")
 		return
 	}
 
 	var input materialUpdateInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &input) {
 		return
 	}
 
 	material.Title = input.Title
 	material.Content = input.Content
 
-	if err := h.db.Save(&material).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update material"})
+	if err := h.materials.Update(c.Request.Context(), material); err != nil {
+		respondInternalError(c, "Failed to update material")
 		return
 	}
 
@@ -203,14 +215,150 @@ func (h *MaterialHandler) DeleteMaterial(c *gin.Context) {
 
 	materialID, err := uuid.Parse(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid material ID"})
+		respondInvalidID(c, "material")
 		return
 	}
 
-	if err := h.db.Where("id = ?", materialID).Delete(&models.Material{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete material"})
+	if err := h.materials.Delete(c.Request.Context(), materialID); err != nil {
+		respondInternalError(c, "Failed to delete material")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Material deleted successfully"})
 }
+
+// AttachMaterial 既存の参考資料を同じ作者の別のBookからも参照できるようにする
+func (h *MaterialHandler) AttachMaterial(c *gin.Context) {
+	bookUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondInvalidID(c, "book")
+		return
+	}
+
+	var input materialAttachInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	book, err := h.books.Get(c.Request.Context(), bookUUID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondNotFound(c, "Book not found")
+			return
+		}
+		respondInternalError(c, "Failed to verify book")
+		return
+	}
+
+	material, err := h.materials.Get(c.Request.Context(), input.MaterialID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondNotFound(c, "Material not found")
+			return
+		}
+		respondInternalError(c, "Failed to fetch material")
+		return
+	}
+
+	if material.BookID != nil && *material.BookID == bookUUID {
+		respondProblem(c, NewProblem(http.StatusConflict, CodeConflict, "Material already belongs to this book"))
+		return
+	}
+
+	// 共有できるのは同じ作者の資料のみ（他の作者のライブラリや他のシリーズの資料は紐付けない）
+	owner, err := h.materials.Owner(c.Request.Context(), material)
+	if err != nil {
+		respondInternalError(c, "Failed to verify material")
+		return
+	}
+	if owner == uuid.Nil || owner != book.AuthorID {
+		respondProblem(c, NewProblem(http.StatusUnprocessableEntity, CodeForeignMaterial,
+			"Material belongs to another author"))
+		return
+	}
+
+	if err := h.materials.Attach(c.Request.Context(), bookUUID, material.ID); err != nil {
+		respondInternalError(c, "Failed to attach material")
+		return
+	}
+
+	c.JSON(http.StatusOK, material)
+}
+
+// DetachMaterial 共有していた参考資料をBookから外す（資料自体は削除しない）
+func (h *MaterialHandler) DetachMaterial(c *gin.Context) {
+	bookUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondInvalidID(c, "book")
+		return
+	}
+
+	materialID, err := uuid.Parse(c.Param("material_id"))
+	if err != nil {
+		respondInvalidID(c, "material")
+		return
+	}
+
+	if err := h.materials.Detach(c.Request.Context(), bookUUID, materialID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondNotFound(c, "Material is not attached to this book")
+			return
+		}
+		respondInternalError(c, "Failed to detach material")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Material detached successfully"})
+}
+
+// CreateLibraryMaterial 作者ライブラリに参考資料を作成（作者のすべてのBookから参照できる）
+func (h *MaterialHandler) CreateLibraryMaterial(c *gin.Context) {
+	authorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondInvalidID(c, "author")
+		return
+	}
+
+	var input materialCreateInput
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	// Generate UUIDv7 for the new material
+	newID, err := uuid.NewV7()
+	if err != nil {
+		respondInternalError(c, "Failed to generate UUID")
+		return
+	}
+
+	material := models.Material{
+		ID:       newID,
+		AuthorID: &authorID,
+		Title:    input.Title,
+		Content:  input.Content,
+	}
+
+	if err := h.materials.Create(c.Request.Context(), &material); err != nil {
+		respondInternalError(c, "Failed to create material")
+		return
+	}
+
+	c.JSON(http.StatusCreated, material)
+}
+
+// GetLibraryMaterials 作者ライブラリの参考資料を取得
+func (h *MaterialHandler) GetLibraryMaterials(c *gin.Context) {
+	authorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondInvalidID(c, "author")
+		return
+	}
+
+	materials, err := h.materials.ListLibrary(c.Request.Context(), authorID)
+	if err != nil {
+		respondInternalError(c, "Failed to fetch materials")
+		return
+	}
+
+	c.JSON(http.StatusOK, materials)
+}
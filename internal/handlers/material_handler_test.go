@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func newMaterialRouter(materials repository.MaterialRepository, books repository.BookRepository) *gin.Engine {
+	h := NewMaterialHandler(materials, books)
+	router := gin.New()
+	router.POST("/books/:id/materials", h.CreateMaterial)
+	router.GET("/books/:id/materials", h.GetMaterials)
+	router.POST("/books/:id/materials/batch", h.GetMaterialsByIDs)
+	router.POST("/books/:id/materials/attach", h.AttachMaterial)
+	router.DELETE("/books/:id/materials/:material_id", h.DetachMaterial)
+	router.GET("/materials/:id", h.GetMaterial)
+	router.PUT("/materials/:id", h.UpdateMaterial)
+	router.DELETE("/materials/:id", h.DeleteMaterial)
+	router.POST("/authors/:id/materials", h.CreateLibraryMaterial)
+	router.GET("/authors/:id/materials", h.GetLibraryMaterials)
+	return router
+}
+
+// putMaterial リポジトリに参考資料を直接登録する
+func putMaterial(t *testing.T, materials repository.MaterialRepository, material models.Material) models.Material {
+	t.Helper()
+	if material.ID == uuid.Nil {
+		material.ID = uuid.New()
+	}
+	if err := materials.Create(context.Background(), &material); err != nil {
+		t.Fatalf("create material: %v", err)
+	}
+	return material
+}
+
+func materialIDs(materials []models.Material) []string {
+	ids := make([]string, len(materials))
+	for i, material := range materials {
+		ids[i] = material.ID.String()
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestCreateMaterial(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	materials := repository.NewMemoryMaterialRepository()
+	router := newMaterialRouter(materials, books)
+	book := putBook(t, books, models.Book{Title: "Book"})
+
+	rec := serve(t, router, http.MethodPost, "/books/"+book.ID.String()+"/materials", map[string]string{
+		"title":   "Setting",
+		"content": "The world",
+	}, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+
+	var created models.Material
+	decode(t, rec, &created)
+	if created.BookID == nil || *created.BookID != book.ID {
+		t.Errorf("book_id = %v, want %s", created.BookID, book.ID)
+	}
+	if _, err := materials.Get(context.Background(), created.ID); err != nil {
+		t.Errorf("material was not stored: %v", err)
+	}
+}
+
+func TestCreateMaterialValidation(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	router := newMaterialRouter(repository.NewMemoryMaterialRepository(), books)
+	book := putBook(t, books, models.Book{Title: "Book"})
+
+	rec := serve(t, router, http.MethodPost, "/books/"+book.ID.String()+"/materials", map[string]string{
+		"title": "Setting",
+	}, nil)
+	problem := expectProblem(t, rec, http.StatusBadRequest, CodeValidationFailed)
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "content" || problem.Errors[0].Code != "required" {
+		t.Errorf("errors = %+v, want content required", problem.Errors)
+	}
+
+	rec = serve(t, router, http.MethodPost, "/books/"+uuid.NewString()+"/materials", map[string]string{
+		"title":   "Setting",
+		"content": "The world",
+	}, nil)
+	expectProblem(t, rec, http.StatusNotFound, CodeNotFound)
+}
+
+func TestGetMaterialsVisibility(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	materials := repository.NewMemoryMaterialRepository()
+	router := newMaterialRouter(materials, books)
+
+	authorID := uuid.New()
+	seriesID := uuid.New()
+	book := putBook(t, books, models.Book{Title: "Book", AuthorID: authorID, SeriesID: &seriesID})
+	other := putBook(t, books, models.Book{Title: "Other", AuthorID: authorID})
+
+	own := putMaterial(t, materials, models.Material{BookID: &book.ID})
+	series := putMaterial(t, materials, models.Material{SeriesID: &seriesID})
+	library := putMaterial(t, materials, models.Material{AuthorID: &authorID})
+	shared := putMaterial(t, materials, models.Material{BookID: &other.ID})
+	if err := materials.Attach(context.Background(), book.ID, shared.ID); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	otherAuthor := uuid.New()
+	putMaterial(t, materials, models.Material{BookID: &other.ID})
+	putMaterial(t, materials, models.Material{AuthorID: &otherAuthor})
+
+	rec := serve(t, router, http.MethodGet, "/books/"+book.ID.String()+"/materials", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var got []models.Material
+	decode(t, rec, &got)
+	want := materialIDs([]models.Material{own, series, library, shared})
+	if ids := materialIDs(got); !slices.Equal(ids, want) {
+		t.Errorf("materials = %v, want %v", ids, want)
+	}
+}
+
+func TestGetMaterialsByIDsFiltersByBook(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	materials := repository.NewMemoryMaterialRepository()
+	router := newMaterialRouter(materials, books)
+	book := putBook(t, books, models.Book{Title: "Book"})
+	other := putBook(t, books, models.Book{Title: "Other"})
+
+	own := putMaterial(t, materials, models.Material{BookID: &book.ID})
+	hidden := putMaterial(t, materials, models.Material{BookID: &other.ID})
+
+	rec := serve(t, router, http.MethodPost, "/books/"+book.ID.String()+"/materials/batch",
+		map[string]interface{}{"ids": []uuid.UUID{own.ID, hidden.ID}}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var got []models.Material
+	decode(t, rec, &got)
+	if len(got) != 1 || got[0].ID != own.ID {
+		t.Errorf("materials = %v, want only %s", materialIDs(got), own.ID)
+	}
+}
+
+func TestAttachAndDetachMaterial(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	materials := repository.NewMemoryMaterialRepository()
+	router := newMaterialRouter(materials, books)
+	authorID := uuid.New()
+	book := putBook(t, books, models.Book{Title: "Book", AuthorID: authorID})
+	other := putBook(t, books, models.Book{Title: "Other", AuthorID: authorID})
+	materials.PutAuthor(other.ID, authorID)
+	material := putMaterial(t, materials, models.Material{BookID: &other.ID})
+
+	path := "/books/" + book.ID.String() + "/materials"
+	rec := serve(t, router, http.MethodPost, path+"/attach", map[string]uuid.UUID{"material_id": material.ID}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("attach: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	visible, _ := materials.ListVisible(context.Background(), &book)
+	if len(visible) != 1 {
+		t.Errorf("visible after attach = %v, want the attached material", materialIDs(visible))
+	}
+
+	rec = serve(t, router, http.MethodDelete, path+"/"+material.ID.String(), nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("detach: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	// 紐付いていない資料を外そうとした場合は404
+	rec = serve(t, router, http.MethodDelete, path+"/"+material.ID.String(), nil, nil)
+	expectProblem(t, rec, http.StatusNotFound, CodeNotFound)
+
+	// 資料の元のBookには紐付けられない
+	rec = serve(t, router, http.MethodPost, "/books/"+other.ID.String()+"/materials/attach",
+		map[string]uuid.UUID{"material_id": material.ID}, nil)
+	expectProblem(t, rec, http.StatusConflict, CodeConflict)
+}
+
+func TestAttachMaterialFromAnotherAuthor(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	materials := repository.NewMemoryMaterialRepository()
+	router := newMaterialRouter(materials, books)
+	authorID, otherAuthor := uuid.New(), uuid.New()
+	book := putBook(t, books, models.Book{Title: "Book", AuthorID: authorID})
+	foreignBook := putBook(t, books, models.Book{Title: "Other", AuthorID: otherAuthor})
+	foreignSeries := uuid.New()
+	materials.PutAuthor(foreignBook.ID, otherAuthor)
+	materials.PutAuthor(foreignSeries, otherAuthor)
+
+	tests := []struct {
+		name     string
+		material models.Material
+	}{
+		{name: "other author's library", material: models.Material{AuthorID: &otherAuthor}},
+		{name: "other author's series", material: models.Material{SeriesID: &foreignSeries}},
+		{name: "other author's book", material: models.Material{BookID: &foreignBook.ID}},
+		{name: "no author", material: models.Material{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			material := putMaterial(t, materials, tt.material)
+			rec := serve(t, router, http.MethodPost, "/books/"+book.ID.String()+"/materials/attach",
+				map[string]uuid.UUID{"material_id": material.ID}, nil)
+			expectProblem(t, rec, http.StatusUnprocessableEntity, CodeForeignMaterial)
+		})
+	}
+
+	visible, _ := materials.ListVisible(context.Background(), &book)
+	if len(visible) != 0 {
+		t.Errorf("visible = %v, want none", materialIDs(visible))
+	}
+}
+
+func TestLibraryMaterials(t *testing.T) {
+	materials := repository.NewMemoryMaterialRepository()
+	router := newMaterialRouter(materials, repository.NewMemoryBookRepository())
+	authorID := uuid.New()
+	path := "/authors/" + authorID.String() + "/materials"
+
+	for _, title := range []string{"First", "Second"} {
+		rec := serve(t, router, http.MethodPost, path, map[string]string{"title": title, "content": "..."}, nil)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create %s: status = %d, want %d (body %s)", title, rec.Code, http.StatusCreated, rec.Body.String())
+		}
+		// 作成日時の順序を確定させる
+		time.Sleep(time.Millisecond)
+	}
+	bookID := uuid.New()
+	putMaterial(t, materials, models.Material{AuthorID: &authorID, BookID: &bookID})
+
+	rec := serve(t, router, http.MethodGet, path, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var got []models.Material
+	decode(t, rec, &got)
+	if len(got) != 2 || got[0].Title != "Second" || got[1].Title != "First" {
+		t.Errorf("materials = %+v, want Second and First", got)
+	}
+}
+
+func TestUpdateAndDeleteMaterial(t *testing.T) {
+	books := repository.NewMemoryBookRepository()
+	materials := repository.NewMemoryMaterialRepository()
+	router := newMaterialRouter(materials, books)
+	book := putBook(t, books, models.Book{Title: "Book"})
+	material := putMaterial(t, materials, models.Material{BookID: &book.ID, Title: "Setting", Content: "..."})
+
+	rec := serve(t, router, http.MethodPut, "/materials/"+material.ID.String(), map[string]string{
+		"title":   "Renamed",
+		"content": "Updated",
+	}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	stored, _ := materials.Get(context.Background(), material.ID)
+	if stored.Title != "Renamed" || stored.Content != "Updated" {
+		t.Errorf("stored = %+v, want renamed and updated", stored)
+	}
+
+	rec = serve(t, router, http.MethodDelete, "/materials/"+material.ID.String(), nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	rec = serve(t, router, http.MethodGet, "/materials/"+material.ID.String(), nil, nil)
+	expectProblem(t, rec, http.StatusNotFound, CodeNotFound)
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"challecara2025-back/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ProblemContentType RFC 9457 の問題詳細のContent-Type
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix 問題の種類を表すURI（type）の接頭辞。後ろに機械可読なコードが付く
+const problemTypePrefix = "urn:challecara:problem:"
+
+// 機械可読なエラーコード
+const (
+	CodeInvalidID               = "invalid_id"
+	CodeInvalidBody             = "invalid_body"
+	CodeValidationFailed        = "validation_failed"
+	CodeNotFound                = "not_found"
+	CodeConflict                = "conflict"
+	CodeInvalidStatus           = "invalid_status"
+	CodeInvalidStatusTransition = "invalid_status_transition"
+	CodePublishAtRequired       = "publish_at_required"
+	CodeInvalidImageReference   = "invalid_image_reference"
+	CodeForeignMaterial         = "foreign_material"
+	CodeInternal                = "internal_error"
+)
+
+// Problem RFC 9457 の問題詳細（application/problem+json）
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code typeの末尾と同じ機械可読なエラーコード
+	Code string `json:"code"`
+	// Errors 入力のフィールドごとの検証エラー
+	Errors []FieldError `json:"errors,omitempty"`
+	// RequestID ログと突き合わせるためのリクエストID
+	RequestID string `json:"request_id,omitempty"`
+
+	// extensions 問題ごとの追加のメンバー（例: allowed, image_ids）
+	extensions map[string]interface{}
+}
+
+// FieldError 入力のフィールドの検証エラー
+type FieldError struct {
+	// Field JSONのフィールド名（ネストしている場合は "a.b"）
+	Field string `json:"field"`
+	// Code 満たさなかった検証ルール（required, max など）
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewProblem 問題詳細を作成
+func NewProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   problemTypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// With 問題ごとの追加のメンバーを設定する
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.extensions == nil {
+		p.extensions = map[string]interface{}{}
+	}
+	p.extensions[key] = value
+	return p
+}
+
+// MarshalJSON 追加のメンバーを標準のメンバーと同じ階層に出力する
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	data, err := json.Marshal((*problem)(p))
+	if err != nil || len(p.extensions) == 0 {
+		return data, err
+	}
+
+	members := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	for key, value := range p.extensions {
+		if _, ok := members[key]; ok {
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		members[key] = raw
+	}
+	return json.Marshal(members)
+}
+
+// respondProblem 問題詳細を application/problem+json で返す
+func respondProblem(c *gin.Context, p *Problem) {
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	p.RequestID = logging.RequestID(c.Request.Context())
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(p.Status, p)
+}
+
+// respondInvalidID パスのIDが不正な場合の400を返す（resourceは "book" など）
+func respondInvalidID(c *gin.Context, resource string) {
+	respondProblem(c, NewProblem(http.StatusBadRequest, CodeInvalidID, fmt.Sprintf("Invalid %s ID", resource)))
+}
+
+// respondNotFound 対象が見つからない場合の404を返す
+func respondNotFound(c *gin.Context, detail string) {
+	respondProblem(c, NewProblem(http.StatusNotFound, CodeNotFound, detail))
+}
+
+// respondInternalError サーバー側の失敗の500を返す（内部のエラーの内容は返さない）
+func respondInternalError(c *gin.Context, detail string) {
+	respondProblem(c, NewProblem(http.StatusInternalServerError, CodeInternal, detail))
+}
+
+var registerFieldNames sync.Once
+
+// bindJSON リクエストの本文を読み取り、失敗した場合はフィールドごとの検証エラーを含む400を返す
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	// 検証エラーのフィールド名をGoのフィールド名ではなくJSONの名前にする
+	registerFieldNames.Do(func() {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			v.RegisterTagNameFunc(jsonFieldName)
+		}
+	})
+
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+	respondProblem(c, bindingProblem(err))
+	return false
+}
+
+// bindingProblem 本文の読み取り・検証のエラーを問題詳細に変換する
+func bindingProblem(err error) *Problem {
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+	var syntaxError *json.SyntaxError
+
+	switch {
+	case errors.As(err, &validationErrors):
+		problem := NewProblem(http.StatusBadRequest, CodeValidationFailed, "Request body has invalid fields")
+		for _, fe := range validationErrors {
+			problem.Errors = append(problem.Errors, FieldError{
+				Field:   fieldPath(fe),
+				Code:    fe.Tag(),
+				Message: validationMessage(fe),
+			})
+		}
+		return problem
+	case errors.As(err, &typeError):
+		problem := NewProblem(http.StatusBadRequest, CodeValidationFailed, "Request body has invalid fields")
+		problem.Errors = []FieldError{{
+			Field:   typeError.Field,
+			Code:    "type",
+			Message: fmt.Sprintf("must be %s", jsonTypeName(typeError.Type)),
+		}}
+		return problem
+	case errors.As(err, &syntaxError), errors.Is(err, io.ErrUnexpectedEOF):
+		return NewProblem(http.StatusBadRequest, CodeInvalidBody, "Request body is not valid JSON")
+	case errors.Is(err, io.EOF):
+		return NewProblem(http.StatusBadRequest, CodeInvalidBody, "Request body is empty")
+	}
+	return NewProblem(http.StatusBadRequest, CodeInvalidBody, "Request body could not be parsed")
+}
+
+// fieldPath 検証エラーのフィールドをトップレベルの型名を除いたJSONのパスで返す
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fe.Field()
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of " + fe.Param()
+	}
+	return "is invalid"
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"challecara2025-back/internal/models"
+	"challecara2025-back/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PublicHandler 読者向けの読み取り専用API（公開済みのBook・エピソードのみを返し、参考資料は返さない）
+type PublicHandler struct {
+	db    *gorm.DB
+	books repository.BookRepository
+}
+
+func NewPublicHandler(db *gorm.DB, books repository.BookRepository) *PublicHandler {
+	return &PublicHandler{db: db, books: books}
+}
+
+type publicBookSummary struct {
+	ID           uuid.UUID  `json:"id"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	AuthorID     uuid.UUID  `json:"author_id"`
+	CoverImage   string     `json:"cover_image,omitempty"`
+	Genre        string     `json:"genre"`
+	Status       string     `json:"status"`
+	SeriesID     *uuid.UUID `json:"series_id,omitempty"`
+	SeriesOrder  int        `json:"series_order,omitempty"`
+	EpisodeCount int        `json:"episode_count"`
+	PublishedAt  *time.Time `json:"published_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type publicTOCEntry struct {
+	ID          uuid.UUID  `json:"id"`
+	EpisodeNo   int        `json:"episode_no"`
+	Title       string     `json:"title"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+}
+
+type publicBook struct {
+	publicBookSummary
+	Stats           *models.BookStats   `json:"stats"`
+	AuthorStats     *models.AuthorStats `json:"author_stats"`
+	TableOfContents []publicTOCEntry    `json:"table_of_contents"`
+}
+
+type publicEpisodeLink struct {
+	ID        uuid.UUID `json:"id"`
+	EpisodeNo int       `json:"episode_no"`
+	Title     string    `json:"title"`
+}
+
+type publicEpisode struct {
+	ID          uuid.UUID          `json:"id"`
+	BookID      uuid.UUID          `json:"book_id"`
+	BookTitle   string             `json:"book_title"`
+	EpisodeNo   int                `json:"episode_no"`
+	Title       string             `json:"title"`
+	Content     string             `json:"content"`
+	ContentHTML string             `json:"content_html"` // 挿絵を画像に解決したHTML
+	PublishedAt *time.Time         `json:"published_at,omitempty"`
+	Prev        *publicEpisodeLink `json:"prev,omitempty"`
+	Next        *publicEpisodeLink `json:"next,omitempty"`
+}
+
+// GetBooks 公開中のBook一覧を取得（?genre= で絞り込み）
+func (h *PublicHandler) GetBooks(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	var books []models.Book
+
+	query := db.Scopes(models.PublicBooks)
+	if genre := c.Query("genre"); genre != "" {
+		query = query.Where("genre = ?", genre)
+	}
+
+	if err := query.Order("updated_at DESC").Find(&books).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch books"})
+		return
+	}
+
+	bookIDs := make([]uuid.UUID, len(books))
+	for i, book := range books {
+		bookIDs[i] = book.ID
+	}
+	episodeCounts, err := publishedEpisodeCounts(db, bookIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count episodes"})
+		return
+	}
+
+	summaries := make([]publicBookSummary, len(books))
+	for i := range books {
+		summaries[i] = newPublicBookSummary(&books[i], episodeCounts[books[i].ID])
+	}
+
+	c.JSON(http.StatusOK, summaries)
+}
+
+// GetBook 公開中のBookと目次を取得
+func (h *PublicHandler) GetBook(c *gin.Context) {
+	book, ok := h.findPublicBook(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	toc, err := h.tableOfContents(c.Request.Context(), book.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episodes"})
+		return
+	}
+
+	stats, err := h.books.Stats(c.Request.Context(), book.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book stats"})
+		return
+	}
+	authorStats, err := h.books.AuthorStats(c.Request.Context(), book.AuthorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch author stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, publicBook{
+		publicBookSummary: newPublicBookSummary(&book, len(toc)),
+		Stats:             stats,
+		AuthorStats:       authorStats,
+		TableOfContents:   toc,
+	})
+}
+
+// GetTableOfContents 公開中のBookの目次を取得
+func (h *PublicHandler) GetTableOfContents(c *gin.Context) {
+	book, ok := h.findPublicBook(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	toc, err := h.tableOfContents(c.Request.Context(), book.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episodes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toc)
+}
+
+// GetEpisode 公開済みエピソードの本文と前後のエピソードへのリンクを取得
+func (h *PublicHandler) GetEpisode(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	episodeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid episode ID"})
+		return
+	}
+
+	var episode models.Episode
+	if err := db.Scopes(models.PublishedEpisodes).Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episode"})
+		return
+	}
+
+	var book models.Book
+	if err := db.Scopes(models.PublicBooks).Where("id = ?", episode.BookID).First(&book).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return
+	}
+
+	// 閲覧数の記録に失敗しても本文は返す
+	if err := recordEpisodeView(db, &episode, viewerKey(c), time.Now()); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to record episode view", "episode_id", episode.ID, "error", err)
+	}
+
+	prev, err := h.adjacentEpisode(c.Request.Context(), &episode, "episode_no < ?", "episode_no DESC")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episode"})
+		return
+	}
+	next, err := h.adjacentEpisode(c.Request.Context(), &episode, "episode_no > ?", "episode_no")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episode"})
+		return
+	}
+
+	contentHTML, err := renderEpisodeHTML(db, &episode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render episode"})
+		return
+	}
+
+	c.JSON(http.StatusOK, publicEpisode{
+		ID:          episode.ID,
+		BookID:      episode.BookID,
+		BookTitle:   book.Title,
+		EpisodeNo:   episode.EpisodeNo,
+		Title:       episode.Title,
+		Content:     episode.Content,
+		ContentHTML: contentHTML,
+		PublishedAt: episode.PublishedAt,
+		Prev:        prev,
+		Next:        next,
+	})
+}
+
+// findPublicBook 公開中のBookを取得し、失敗時はレスポンスを書き込む
+func (h *PublicHandler) findPublicBook(c *gin.Context, id string) (models.Book, bool) {
+	db := h.db.WithContext(c.Request.Context())
+
+	var book models.Book
+
+	bookID, err := uuid.Parse(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return book, false
+	}
+
+	if err := db.Scopes(models.PublicBooks).Where("id = ?", bookID).First(&book).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+			return book, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch book"})
+		return book, false
+	}
+
+	return book, true
+}
+
+// tableOfContents 公開済みエピソードの目次をエピソード番号順に返す
+func (h *PublicHandler) tableOfContents(ctx context.Context, bookID uuid.UUID) ([]publicTOCEntry, error) {
+	toc := []publicTOCEntry{}
+	err := h.db.WithContext(ctx).Model(&models.Episode{}).Scopes(models.PublishedEpisodes).
+		Select("id, episode_no, title, published_at").
+		Where("book_id = ?", bookID).
+		Order("episode_no").
+		Scan(&toc).Error
+	return toc, err
+}
+
+// adjacentEpisode 同じBookの公開済みエピソードのうち、条件に合う最も近いものを返す
+func (h *PublicHandler) adjacentEpisode(ctx context.Context, episode *models.Episode, cond, order string) (*publicEpisodeLink, error) {
+	var links []publicEpisodeLink
+	err := h.db.WithContext(ctx).Model(&models.Episode{}).Scopes(models.PublishedEpisodes).
+		Select("id, episode_no, title").
+		Where("book_id = ?", episode.BookID).
+		Where(cond, episode.EpisodeNo).
+		Order(order).
+		Limit(1).
+		Scan(&links).Error
+	if err != nil || len(links) == 0 {
+		return nil, err
+	}
+	return &links[0], nil
+}
+
+// publishedEpisodeCounts Bookごとの公開済みエピソード数をまとめて集計
+func publishedEpisodeCounts(db *gorm.DB, bookIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	counts := make(map[uuid.UUID]int, len(bookIDs))
+	if len(bookIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		BookID uuid.UUID
+		Count  int
+	}
+	if err := db.Model(&models.Episode{}).Scopes(models.PublishedEpisodes).
+		Select("book_id, COUNT(*) AS count").
+		Where("book_id IN ?", bookIDs).
+		Group("book_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.BookID] = row.Count
+	}
+	return counts, nil
+}
+
+func newPublicBookSummary(book *models.Book, episodeCount int) publicBookSummary {
+	return publicBookSummary{
+		ID:           book.ID,
+		Title:        book.Title,
+		Description:  book.Description,
+		AuthorID:     book.AuthorID,
+		CoverImage:   book.CoverImage,
+		Genre:        book.Genre,
+		Status:       book.Status,
+		SeriesID:     book.SeriesID,
+		SeriesOrder:  book.SeriesOrder,
+		EpisodeCount: episodeCount,
+		PublishedAt:  book.PublishedAt,
+		CompletedAt:  book.CompletedAt,
+		UpdatedAt:    book.UpdatedAt,
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RankingHandler 定期ジョブで集計済みのランキングを返す
+type RankingHandler struct {
+	db *gorm.DB
+}
+
+func NewRankingHandler(db *gorm.DB) *RankingHandler {
+	return &RankingHandler{db: db}
+}
+
+type rankingEntry struct {
+	Rank        int               `json:"rank"`
+	Score       float64           `json:"score"`
+	Views       int64             `json:"views"`
+	Favorites   int64             `json:"favorites"`
+	NewEpisodes int64             `json:"new_episodes"`
+	Book        publicBookSummary `json:"book"`
+}
+
+type rankingResponse struct {
+	Period     string         `json:"period"`
+	Genre      string         `json:"genre,omitempty"`
+	ComputedAt *time.Time     `json:"computed_at,omitempty"`
+	Items      []rankingEntry `json:"items"`
+}
+
+// GetRankings ランキングを取得（?period=daily|weekly|all_time&genre=&limit=）
+func (h *RankingHandler) GetRankings(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	period := c.DefaultQuery("period", models.RankingPeriodDaily)
+	if !models.IsValidRankingPeriod(period) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period must be daily, weekly or all_time"})
+		return
+	}
+	genre := c.Query("genre")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+	if limit > maxPerPage {
+		limit = maxPerPage
+	}
+
+	var rankings []models.Ranking
+	if err := db.Where("period = ? AND genre = ?", period, genre).
+		Order("position").
+		Limit(limit).
+		Find(&rankings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rankings"})
+		return
+	}
+
+	bookIDs := make([]uuid.UUID, len(rankings))
+	for i, ranking := range rankings {
+		bookIDs[i] = ranking.BookID
+	}
+	var books []models.Book
+	if len(bookIDs) > 0 {
+		if err := db.Scopes(models.PublicBooks).Where("id IN ?", bookIDs).Find(&books).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch books"})
+			return
+		}
+	}
+	booksByID := make(map[uuid.UUID]*models.Book, len(books))
+	for i := range books {
+		booksByID[books[i].ID] = &books[i]
+	}
+	episodeCounts, err := publishedEpisodeCounts(db, bookIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count episodes"})
+		return
+	}
+
+	response := rankingResponse{Period: period, Genre: genre, Items: []rankingEntry{}}
+	for _, ranking := range rankings {
+		if response.ComputedAt == nil {
+			computedAt := ranking.ComputedAt
+			response.ComputedAt = &computedAt
+		}
+		// 集計後に非公開になったBookは除外する（順位は集計時のまま）
+		book, ok := booksByID[ranking.BookID]
+		if !ok {
+			continue
+		}
+		response.Items = append(response.Items, rankingEntry{
+			Rank:        ranking.Rank,
+			Score:       ranking.Score,
+			Views:       ranking.Views,
+			Favorites:   ranking.Favorites,
+			NewEpisodes: ranking.NewEpisodes,
+			Book:        newPublicBookSummary(book, episodeCounts[book.ID]),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReaderHandler 読者ごとのしおり・読書位置を扱う
+type ReaderHandler struct {
+	db *gorm.DB
+}
+
+func NewReaderHandler(db *gorm.DB) *ReaderHandler {
+	return &ReaderHandler{db: db}
+}
+
+type bookmarkCreateInput struct {
+	EpisodeID      uuid.UUID `json:"episode_id" binding:"required"`
+	ScrollPosition float64   `json:"scroll_position" binding:"min=0,max=1"`
+	Note           string    `json:"note" binding:"max=500"`
+}
+
+type progressInput struct {
+	EpisodeID      uuid.UUID `json:"episode_id" binding:"required"`
+	ScrollPosition float64   `json:"scroll_position" binding:"min=0,max=1"`
+}
+
+// continueReadingEntry 「続きから読む」一覧の1件
+type continueReadingEntry struct {
+	Book           publicBookSummary  `json:"book"`
+	EpisodeID      uuid.UUID          `json:"episode_id"`
+	EpisodeNo      int                `json:"episode_no"`
+	ScrollPosition float64            `json:"scroll_position"`
+	NextEpisode    *publicEpisodeLink `json:"next_episode,omitempty"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// GetBookmarks 自分のしおり一覧を取得（?book_id= で絞り込み）
+func (h *ReaderHandler) GetBookmarks(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	query := db.Where("user_id = ?", userID)
+	if bookIDParam := c.Query("book_id"); bookIDParam != "" {
+		bookID, err := uuid.Parse(bookIDParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+			return
+		}
+		query = query.Where("book_id = ?", bookID)
+	}
+
+	var bookmarks []models.Bookmark
+	if err := query.Order("created_at DESC").Find(&bookmarks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookmarks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bookmarks)
+}
+
+// CreateBookmark 公開済みエピソードにしおりを付ける
+func (h *ReaderHandler) CreateBookmark(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input bookmarkCreateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	episode, ok := h.findReadableEpisode(c, input.EpisodeID)
+	if !ok {
+		return
+	}
+
+	// Generate UUIDv7 for the new bookmark
+	newID, err := uuid.NewV7()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate UUID"})
+		return
+	}
+
+	bookmark := models.Bookmark{
+		ID:             newID,
+		UserID:         userID,
+		BookID:         episode.BookID,
+		EpisodeID:      episode.ID,
+		ScrollPosition: input.ScrollPosition,
+		Note:           input.Note,
+	}
+
+	if err := db.Create(&bookmark).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bookmark"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bookmark)
+}
+
+// DeleteBookmark 自分のしおりを削除
+func (h *ReaderHandler) DeleteBookmark(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	bookmarkID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+		return
+	}
+
+	result := db.Where("id = ? AND user_id = ?", bookmarkID, userID).Delete(&models.Bookmark{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bookmark"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark deleted successfully"})
+}
+
+// SaveProgress Bookの読書位置（最後に読んだエピソードとスクロール位置）を保存
+func (h *ReaderHandler) SaveProgress(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	bookID, err := uuid.Parse(c.Param("book_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+
+	var input progressInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	episode, ok := h.findReadableEpisode(c, input.EpisodeID)
+	if !ok {
+		return
+	}
+	if episode.BookID != bookID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Episode does not belong to this book"})
+		return
+	}
+
+	progress := models.ReadingProgress{
+		UserID:         userID,
+		BookID:         bookID,
+		EpisodeID:      episode.ID,
+		EpisodeNo:      episode.EpisodeNo,
+		ScrollPosition: input.ScrollPosition,
+	}
+
+	err = db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "book_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"episode_id", "episode_no", "scroll_position", "updated_at"}),
+	}).Create(&progress).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save progress"})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// GetProgress Bookの読書位置を取得
+func (h *ReaderHandler) GetProgress(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	bookID, err := uuid.Parse(c.Param("book_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+
+	var progress models.ReadingProgress
+	if err := db.Where("user_id = ? AND book_id = ?", userID, bookID).First(&progress).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Progress not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// GetContinueReading 最近読んだ順に「続きから読む」一覧を取得
+func (h *ReaderHandler) GetContinueReading(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var progresses []models.ReadingProgress
+	if err := db.Where("user_id = ?", userID).Order("updated_at DESC").Limit(50).Find(&progresses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
+		return
+	}
+
+	bookIDs := make([]uuid.UUID, len(progresses))
+	for i, progress := range progresses {
+		bookIDs[i] = progress.BookID
+	}
+	var books []models.Book
+	if len(bookIDs) > 0 {
+		if err := db.Scopes(models.PublicBooks).Where("id IN ?", bookIDs).Find(&books).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch books"})
+			return
+		}
+	}
+	booksByID := make(map[uuid.UUID]*models.Book, len(books))
+	for i := range books {
+		booksByID[books[i].ID] = &books[i]
+	}
+	episodeCounts, err := publishedEpisodeCounts(db, bookIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count episodes"})
+		return
+	}
+	next, err := nextEpisodes(db, progresses)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episodes"})
+		return
+	}
+
+	entries := []continueReadingEntry{}
+	for _, progress := range progresses {
+		// 非公開になったBookは一覧に含めない
+		book, ok := booksByID[progress.BookID]
+		if !ok {
+			continue
+		}
+		entries = append(entries, continueReadingEntry{
+			Book:           newPublicBookSummary(book, episodeCounts[book.ID]),
+			EpisodeID:      progress.EpisodeID,
+			EpisodeNo:      progress.EpisodeNo,
+			ScrollPosition: progress.ScrollPosition,
+			UpdatedAt:      progress.UpdatedAt,
+			NextEpisode:    next[progress.BookID],
+		})
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// nextEpisodes 読書の進捗ごとに、読んだ話の次の公開済みエピソードをBookのIDでまとめて取得する
+func nextEpisodes(db *gorm.DB, progresses []models.ReadingProgress) (map[uuid.UUID]*publicEpisodeLink, error) {
+	next := make(map[uuid.UUID]*publicEpisodeLink, len(progresses))
+	if len(progresses) == 0 {
+		return next, nil
+	}
+
+	readNo := make(map[uuid.UUID]int, len(progresses))
+	bookIDs := make([]uuid.UUID, len(progresses))
+	for i, progress := range progresses {
+		readNo[progress.BookID] = progress.EpisodeNo
+		bookIDs[i] = progress.BookID
+	}
+
+	var episodes []models.Episode
+	if err := db.Scopes(models.PublishedEpisodes).
+		Select("id, book_id, episode_no, title").
+		Where("book_id IN ?", bookIDs).
+		Order("episode_no").
+		Find(&episodes).Error; err != nil {
+		return nil, err
+	}
+	// 話数の昇順なので、読んだ話より後の最初のエピソードが次の話
+	for _, episode := range episodes {
+		if _, found := next[episode.BookID]; !found && episode.EpisodeNo > readNo[episode.BookID] {
+			next[episode.BookID] = &publicEpisodeLink{ID: episode.ID, EpisodeNo: episode.EpisodeNo, Title: episode.Title}
+		}
+	}
+	return next, nil
+}
+
+// findReadableEpisode 読者が読める（公開済みの）エピソードを取得し、失敗時はレスポンスを書き込む
+func (h *ReaderHandler) findReadableEpisode(c *gin.Context, episodeID uuid.UUID) (models.Episode, bool) {
+	db := h.db.WithContext(c.Request.Context())
+
+	var episode models.Episode
+
+	err := db.Scopes(models.PublishedEpisodes).
+		Where("id = ? AND book_id IN (?)", episodeID, db.Model(&models.Book{}).Scopes(models.PublicBooks).Select("id")).
+		First(&episode).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Episode not found"})
+			return episode, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch episode"})
+		return episode, false
+	}
+
+	return episode, true
+}
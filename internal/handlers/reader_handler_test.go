@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestGetContinueReading(t *testing.T) {
+	db := newTestDB(t)
+	router := gin.New()
+	router.GET("/me/continue-reading", NewReaderHandler(db).GetContinueReading)
+	userID := uuid.New()
+
+	// 第1話まで読んだBook（第2話は下書きなので次は第3話）、最新話まで読んだBook、非公開のBook
+	reading := models.Book{ID: uuid.New(), Title: "Reading", Status: models.BookStatusPublished}
+	caughtUp := models.Book{ID: uuid.New(), Title: "Caught up", Status: models.BookStatusPublished}
+	draft := models.Book{ID: uuid.New(), Title: "Draft", Status: models.BookStatusDraft}
+	insert(t, db, &reading, &caughtUp, &draft)
+
+	episode := func(book models.Book, no int, status string) models.Episode {
+		e := models.Episode{ID: uuid.New(), BookID: book.ID, Title: "Episode", EpisodeNo: no, Status: status}
+		insert(t, db, &e)
+		return e
+	}
+	read := episode(reading, 1, models.EpisodeStatusPublished)
+	episode(reading, 2, models.EpisodeStatusDraft)
+	want := episode(reading, 3, models.EpisodeStatusPublished)
+	episode(reading, 4, models.EpisodeStatusPublished)
+	episode(caughtUp, 1, models.EpisodeStatusPublished)
+	latest := episode(caughtUp, 2, models.EpisodeStatusPublished)
+	hidden := episode(draft, 1, models.EpisodeStatusPublished)
+	episode(draft, 2, models.EpisodeStatusPublished)
+
+	insert(t, db,
+		&models.ReadingProgress{UserID: userID, BookID: reading.ID, EpisodeID: read.ID, EpisodeNo: 1},
+		&models.ReadingProgress{UserID: userID, BookID: caughtUp.ID, EpisodeID: latest.ID, EpisodeNo: 2},
+		&models.ReadingProgress{UserID: userID, BookID: draft.ID, EpisodeID: hidden.ID, EpisodeNo: 1},
+	)
+
+	// 進捗の件数によらず、一定の回数のクエリで取得する
+	queries := 0
+	count := func(*gorm.DB) { queries++ }
+	db.Callback().Query().After("gorm:query").Register("test:count_queries", count)
+	db.Callback().Row().After("gorm:row").Register("test:count_rows", count)
+
+	rec := serve(t, router, http.MethodGet, "/me/continue-reading", nil, asUser(userID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if queries != 4 {
+		t.Errorf("queries = %d, want 4", queries)
+	}
+
+	var entries []continueReadingEntry
+	decode(t, rec, &entries)
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, want 2 (draft book excluded)", len(entries))
+	}
+	for _, entry := range entries {
+		switch entry.Book.ID {
+		case reading.ID:
+			if entry.NextEpisode == nil || entry.NextEpisode.ID != want.ID || entry.NextEpisode.EpisodeNo != 3 {
+				t.Errorf("next episode = %+v, want episode 3", entry.NextEpisode)
+			}
+			if entry.Book.EpisodeCount != 3 {
+				t.Errorf("episode count = %d, want 3", entry.Book.EpisodeCount)
+			}
+		case caughtUp.ID:
+			if entry.NextEpisode != nil {
+				t.Errorf("next episode = %+v, want none", entry.NextEpisode)
+			}
+		default:
+			t.Errorf("unexpected book %s", entry.Book.ID)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// errBookInSeries 巻が既にシリーズに含まれている
+	errBookInSeries = errors.New("book already belongs to a series")
+	// errInvalidReorder 並べ替えの指定がシリーズの全巻と一致しない
+	errInvalidReorder = errors.New("book_ids must list every book in the series exactly once")
+)
+
+type SeriesHandler struct {
+	db *gorm.DB
+}
+
+func NewSeriesHandler(db *gorm.DB) *SeriesHandler {
+	return &SeriesHandler{db: db}
+}
+
+type seriesInput struct {
+	Title       string    `json:"title" binding:"required"`
+	Description string    `json:"description"`
+	AuthorID    uuid.UUID `json:"author_id"`
+}
+
+type seriesBookInput struct {
+	BookID uuid.UUID `json:"book_id" binding:"required"`
+}
+
+type seriesReorderInput struct {
+	BookIDs []uuid.UUID `json:"book_ids" binding:"required"`
+}
+
+// seriesBookSummary シリーズ詳細で返す各巻の概要
+type seriesBookSummary struct {
+	ID           uuid.UUID `json:"id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	CoverImage   string    `json:"cover_image,omitempty"`
+	Genre        string    `json:"genre"`
+	Status       string    `json:"status"`
+	SeriesOrder  int       `json:"series_order"`
+	EpisodeCount int64     `json:"episode_count"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type seriesDetail struct {
+	models.Series
+	Books []seriesBookSummary `json:"books"`
+}
+
+// CreateSeries 新しいシリーズを作成
+func (h *SeriesHandler) CreateSeries(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	var input seriesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Generate UUIDv7 for the new series
+	newID, err := uuid.NewV7()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate UUID"})
+		return
+	}
+
+	series := models.Series{
+		ID:          newID,
+		Title:       input.Title,
+		Description: input.Description,
+		AuthorID:    input.AuthorID,
+	}
+
+	if err := db.Create(&series).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create series"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, series)
+}
+
+// GetSeriesList すべてのシリーズを取得
+func (h *SeriesHandler) GetSeriesList(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	var seriesList []models.Series
+
+	if err := db.Order("created_at DESC").Find(&seriesList).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, seriesList)
+}
+
+// GetSeries シリーズと各巻の概要を取得
+func (h *SeriesHandler) GetSeries(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	series, ok := h.findSeries(c)
+	if !ok {
+		return
+	}
+
+	var books []models.Book
+	if err := db.Where("series_id = ?", series.ID).Order("series_order").Find(&books).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch books"})
+		return
+	}
+
+	// 各巻のエピソード数をまとめて集計
+	bookIDs := make([]uuid.UUID, len(books))
+	for i, book := range books {
+		bookIDs[i] = book.ID
+	}
+	var counts []struct {
+		BookID uuid.UUID
+		Count  int64
+	}
+	if len(bookIDs) > 0 {
+		if err := db.Model(&models.Episode{}).
+			Select("book_id, COUNT(*) AS count").
+			Where("book_id IN ?", bookIDs).
+			Group("book_id").
+			Scan(&counts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count episodes"})
+			return
+		}
+	}
+	episodeCounts := make(map[uuid.UUID]int64, len(counts))
+	for _, count := range counts {
+		episodeCounts[count.BookID] = count.Count
+	}
+
+	detail := seriesDetail{Series: series, Books: make([]seriesBookSummary, len(books))}
+	for i, book := range books {
+		detail.Books[i] = seriesBookSummary{
+			ID:           book.ID,
+			Title:        book.Title,
+			Description:  book.Description,
+			CoverImage:   book.CoverImage,
+			Genre:        book.Genre,
+			Status:       book.Status,
+			SeriesOrder:  book.SeriesOrder,
+			EpisodeCount: episodeCounts[book.ID],
+			UpdatedAt:    book.UpdatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// UpdateSeries シリーズを更新
+func (h *SeriesHandler) UpdateSeries(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	series, ok := h.findSeries(c)
+	if !ok {
+		return
+	}
+
+	var input seriesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series.Title = input.Title
+	series.Description = input.Description
+	series.AuthorID = input.AuthorID
+
+	if err := db.Save(&series).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// DeleteSeries シリーズを削除（各巻はシリーズから外すだけで削除しない）
+func (h *SeriesHandler) DeleteSeries(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	series, ok := h.findSeries(c)
+	if !ok {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Book{}).Where("series_id = ?", series.ID).
+			Updates(map[string]interface{}{"series_id": nil, "series_order": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&series).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Series deleted successfully"})
+}
+
+// AddBook シリーズの末尾に巻を追加
+func (h *SeriesHandler) AddBook(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	series, ok := h.findSeries(c)
+	if !ok {
+		return
+	}
+
+	var input seriesBookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 同時に追加された巻が同じ巻数にならないよう、シリーズの行をロックしてから最後の巻数を求める
+	var book models.Book
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockSeries(tx, series.ID); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", input.BookID).First(&book).Error; err != nil {
+			return err
+		}
+		if book.SeriesID != nil {
+			return errBookInSeries
+		}
+
+		var lastOrder int
+		if err := tx.Model(&models.Book{}).Where("series_id = ?", series.ID).
+			Select("COALESCE(MAX(series_order), 0)").Scan(&lastOrder).Error; err != nil {
+			return err
+		}
+
+		book.SeriesID = &series.ID
+		book.SeriesOrder = lastOrder + 1
+		return tx.Model(&book).Select("series_id", "series_order").Updates(&book).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		case errors.Is(err, errBookInSeries):
+			c.JSON(http.StatusConflict, gin.H{"error": "Book already belongs to a series"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add book to series"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, book)
+}
+
+// RemoveBook シリーズから巻を外し、残りの巻を詰めて並べ直す
+func (h *SeriesHandler) RemoveBook(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	series, ok := h.findSeries(c)
+	if !ok {
+		return
+	}
+
+	bookID, err := uuid.Parse(c.Param("book_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := lockSeries(tx, series.ID); err != nil {
+			return err
+		}
+		result := tx.Model(&models.Book{}).Where("id = ? AND series_id = ?", bookID, series.ID).
+			Updates(map[string]interface{}{"series_id": nil, "series_order": 0})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		var books []models.Book
+		if err := tx.Where("series_id = ?", series.ID).Order("series_order").Find(&books).Error; err != nil {
+			return err
+		}
+		for i, book := range books {
+			if err := tx.Model(&book).Update("series_order", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found in series"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove book from series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Book removed from series successfully"})
+}
+
+// ReorderBooks シリーズ内の巻の順番を並べ替える
+func (h *SeriesHandler) ReorderBooks(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	series, ok := h.findSeries(c)
+	if !ok {
+		return
+	}
+
+	var input seriesReorderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 巻の追加・削除と同時に実行されないよう、シリーズの行をロックしてから巻を確認して並べ替える
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockSeries(tx, series.ID); err != nil {
+			return err
+		}
+		var books []models.Book
+		if err := tx.Where("series_id = ?", series.ID).Find(&books).Error; err != nil {
+			return err
+		}
+
+		// 指定されたIDがシリーズの全巻を過不足なく含んでいるか確認
+		current := make(map[uuid.UUID]bool, len(books))
+		for _, book := range books {
+			current[book.ID] = true
+		}
+		if len(input.BookIDs) != len(books) {
+			return errInvalidReorder
+		}
+		for _, id := range input.BookIDs {
+			if !current[id] {
+				return errInvalidReorder
+			}
+			delete(current, id)
+		}
+
+		for i, id := range input.BookIDs {
+			if err := tx.Model(&models.Book{}).Where("id = ? AND series_id = ?", id, series.ID).Update("series_order", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidReorder):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder books"})
+		}
+		return
+	}
+
+	h.GetSeries(c)
+}
+
+// lockSeries 巻の追加・削除・並べ替えを直列化するため、トランザクション内でシリーズの行をロックする
+func lockSeries(tx *gorm.DB, seriesID uuid.UUID) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", seriesID).First(&models.Series{}).Error
+}
+
+// CreateSeriesMaterial シリーズの全巻で共有する参考資料を作成
+func (h *SeriesHandler) CreateSeriesMaterial(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	series, ok := h.findSeries(c)
+	if !ok {
+		return
+	}
+
+	var input materialCreateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Generate UUIDv7 for the new material
+	newID, err := uuid.NewV7()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate UUID"})
+		return
+	}
+
+	material := models.Material{
+		ID:       newID,
+		SeriesID: &series.ID,
+		Title:    input.Title,
+		Content:  input.Content,
+	}
+
+	if err := db.Create(&material).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create material"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, material)
+}
+
+// GetSeriesMaterials シリーズ共有の参考資料を取得
+func (h *SeriesHandler) GetSeriesMaterials(c *gin.Context) {
+	db := h.db.WithContext(c.Request.Context())
+
+	series, ok := h.findSeries(c)
+	if !ok {
+		return
+	}
+
+	var materials []models.Material
+	if err := db.Where("series_id = ?", series.ID).Order("created_at DESC").Find(&materials).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch materials"})
+		return
+	}
+
+	c.JSON(http.StatusOK, materials)
+}
+
+// findSeries パスパラメータのIDからシリーズを取得し、失敗時はレスポンスを書き込む
+func (h *SeriesHandler) findSeries(c *gin.Context) (models.Series, bool) {
+	db := h.db.WithContext(c.Request.Context())
+
+	var series models.Series
+
+	seriesID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return series, false
+	}
+
+	if err := db.Where("id = ?", seriesID).First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+			return series, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series"})
+		return series, false
+	}
+
+	return series, true
+}
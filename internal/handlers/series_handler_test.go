@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestAddBookAssignsDistinctOrders(t *testing.T) {
+	db := newTestDB(t)
+	router := gin.New()
+	router.POST("/series/:id/books", NewSeriesHandler(db).AddBook)
+
+	series := models.Series{ID: uuid.New(), Title: "Series"}
+	insert(t, db, &series)
+	books := make([]models.Book, 5)
+	for i := range books {
+		books[i] = models.Book{ID: uuid.New(), Title: "Book", Status: models.BookStatusDraft}
+		insert(t, db, &books[i])
+	}
+
+	// 同時に追加しても巻数は重複しない
+	var wg sync.WaitGroup
+	for _, book := range books {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := serve(t, router, http.MethodPost, "/series/"+series.ID.String()+"/books",
+				map[string]uuid.UUID{"book_id": book.ID}, nil)
+			if rec.Code != http.StatusOK {
+				t.Errorf("add %s: status = %d, want %d (body %s)", book.ID, rec.Code, http.StatusOK, rec.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+
+	var orders []int
+	if err := db.Model(&models.Book{}).Where("series_id = ?", series.ID).Pluck("series_order", &orders).Error; err != nil {
+		t.Fatalf("fetch orders: %v", err)
+	}
+	sort.Ints(orders)
+	for i, order := range orders {
+		if order != i+1 {
+			t.Fatalf("series orders = %v, want 1..%d", orders, len(books))
+		}
+	}
+	if len(orders) != len(books) {
+		t.Errorf("series orders = %v, want %d books", orders, len(books))
+	}
+
+	// 既にシリーズに含まれる巻は追加できない
+	rec := serve(t, router, http.MethodPost, "/series/"+series.ID.String()+"/books",
+		map[string]uuid.UUID{"book_id": books[0].ID}, nil)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("add again: status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	rec = serve(t, router, http.MethodPost, "/series/"+series.ID.String()+"/books",
+		map[string]uuid.UUID{"book_id": uuid.New()}, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown book: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestReorderBooks(t *testing.T) {
+	db := newTestDB(t)
+	h := NewSeriesHandler(db)
+	router := gin.New()
+	router.GET("/series/:id", h.GetSeries)
+	router.PUT("/series/:id/books/order", h.ReorderBooks)
+	router.DELETE("/series/:id/books/:book_id", h.RemoveBook)
+
+	series := models.Series{ID: uuid.New(), Title: "Series"}
+	insert(t, db, &series)
+	books := make([]models.Book, 3)
+	for i := range books {
+		books[i] = models.Book{ID: uuid.New(), Title: "Book", Status: models.BookStatusDraft, SeriesID: &series.ID, SeriesOrder: i + 1}
+		insert(t, db, &books[i])
+	}
+	outside := models.Book{ID: uuid.New(), Title: "Outside", Status: models.BookStatusDraft}
+	insert(t, db, &outside)
+	path := "/series/" + series.ID.String() + "/books/order"
+
+	orderOf := func(id uuid.UUID) (*uuid.UUID, int) {
+		var book models.Book
+		if err := db.Where("id = ?", id).First(&book).Error; err != nil {
+			t.Fatalf("fetch book: %v", err)
+		}
+		return book.SeriesID, book.SeriesOrder
+	}
+
+	rec := serve(t, router, http.MethodPut, path,
+		map[string][]uuid.UUID{"book_ids": {books[2].ID, books[0].ID, books[1].ID}}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("reorder: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	for i, book := range []models.Book{books[2], books[0], books[1]} {
+		if _, order := orderOf(book.ID); order != i+1 {
+			t.Errorf("order of book %d = %d, want %d", i, order, i+1)
+		}
+	}
+
+	// シリーズ外の巻は並べ替えに含められず、番号も付かない
+	rec = serve(t, router, http.MethodPut, path,
+		map[string][]uuid.UUID{"book_ids": {books[0].ID, books[1].ID, outside.ID}}, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("reorder with outside book: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if seriesID, order := orderOf(outside.ID); seriesID != nil || order != 0 {
+		t.Errorf("outside book series_id = %v, order = %d, want none", seriesID, order)
+	}
+
+	// 外した巻を含む並べ替えは、削除と同時でも外した巻に番号を付けない
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		serve(t, router, http.MethodPut, path,
+			map[string][]uuid.UUID{"book_ids": {books[0].ID, books[1].ID, books[2].ID}}, nil)
+	}()
+	go func() {
+		defer wg.Done()
+		rec := serve(t, router, http.MethodDelete, "/series/"+series.ID.String()+"/books/"+books[0].ID.String(), nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Errorf("remove: status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body.String())
+		}
+	}()
+	wg.Wait()
+
+	if seriesID, order := orderOf(books[0].ID); seriesID != nil || order != 0 {
+		t.Errorf("removed book series_id = %v, order = %d, want none", seriesID, order)
+	}
+	var orders []int
+	if err := db.Model(&models.Book{}).Where("series_id = ?", series.ID).Order("series_order").Pluck("series_order", &orders).Error; err != nil {
+		t.Fatalf("fetch orders: %v", err)
+	}
+	if len(orders) != 2 || orders[0] != 1 || orders[1] != 2 {
+		t.Errorf("series orders = %v, want [1 2]", orders)
+	}
+}
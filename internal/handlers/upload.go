@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+
+	"challecara2025-back/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead ファイル以外のマルチパートのヘッダー等に許容するサイズ
+const multipartOverhead = 1 << 20
+
+// readUpload マルチパートのfileフィールドを最大maxSizeバイトまで読み込み、失敗時はレスポンスを書き込む
+func readUpload(c *gin.Context, maxSize int64) ([]byte, *multipart.FileHeader, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+			return nil, nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return nil, nil, false
+	}
+	if fileHeader.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+		return nil, nil, false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return nil, nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return nil, nil, false
+	}
+	if int64(len(data)) > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+		return nil, nil, false
+	}
+
+	return data, fileHeader, true
+}
+
+// deleteObjects ストレージのオブジェクトを削除する（後片付けなので失敗してもログに残すだけ）
+func deleteObjects(store storage.Storage, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := store.Delete(context.Background(), key); err != nil {
+			slog.Error("Failed to delete object", "key", key, "error", err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UserIDHeader 利用者を識別するヘッダー（認証実装までの暫定）
+const UserIDHeader = "X-User-ID"
+
+// currentUserID リクエストの利用者IDを取得し、無い場合は401を返す
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetHeader(UserIDHeader))
+	if err != nil || userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid " + UserIDHeader + " header"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// optionalUserID リクエストの利用者IDを取得する（無い場合はuuid.Nil）
+func optionalUserID(c *gin.Context) uuid.UUID {
+	userID, err := uuid.Parse(c.GetHeader(UserIDHeader))
+	if err != nil {
+		return uuid.Nil
+	}
+	return userID
+}
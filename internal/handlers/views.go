@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SessionIDHeader 未ログインの読者を識別するためのセッションIDヘッダー
+const SessionIDHeader = "X-Session-ID"
+
+// viewerKey 閲覧の重複排除に使う読者のキー（利用者ID > セッションID > IPとUser-Agent の順）をハッシュ化して返す
+func viewerKey(c *gin.Context) string {
+	var source string
+	if userID := optionalUserID(c); userID != uuid.Nil {
+		source = "user:" + userID.String()
+	} else if sessionID := c.GetHeader(SessionIDHeader); sessionID != "" {
+		source = "session:" + sessionID
+	} else {
+		source = "client:" + c.ClientIP() + "|" + c.Request.UserAgent()
+	}
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordEpisodeView エピソードの閲覧を記録する。同じ読者の同じ日の閲覧は1回として数え、
+// 初回の閲覧のみ日ごとの閲覧数とBookの累計閲覧数に加算する
+func recordEpisodeView(db *gorm.DB, episode *models.Episode, viewer string, now time.Time) error {
+	newID, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		view := models.EpisodeView{
+			ID:        newID,
+			EpisodeID: episode.ID,
+			BookID:    episode.BookID,
+			ViewerKey: viewer,
+			Day:       models.StartOfDay(now),
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&view)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		daily := models.EpisodeViewDaily{
+			EpisodeID: episode.ID,
+			Day:       models.StartOfDay(now),
+			BookID:    episode.BookID,
+		}
+		if err := bumpCounter(tx, &daily, "views", 1); err != nil {
+			return err
+		}
+		return bumpCounter(tx, &models.BookStats{BookID: episode.BookID}, "view_count", 1)
+	})
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GORMLogger GORMのログをslogに出力するロガー。
+// SQLはプレースホルダーのまま出力し、エピソードの本文などの値はログに含めない
+type GORMLogger struct {
+	level     gormlogger.LogLevel
+	slowQuery time.Duration
+}
+
+// NewGORMLogger slowQueryより時間のかかったクエリを警告として出力するロガーを作成
+func NewGORMLogger(slowQuery time.Duration) *GORMLogger {
+	return &GORMLogger{level: gormlogger.Info, slowQuery: slowQuery}
+}
+
+func (l *GORMLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+func (l *GORMLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		slog.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GORMLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		slog.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GORMLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		slog.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace クエリの結果を出力する。失敗は error、遅いクエリは warn、それ以外は debug
+func (l *GORMLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+
+	var level slog.Level
+	var msg string
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		level, msg = slog.LevelError, "query failed"
+	case l.slowQuery > 0 && elapsed > l.slowQuery && l.level >= gormlogger.Warn:
+		level, msg = slog.LevelWarn, "slow query"
+	case l.level >= gormlogger.Info:
+		level, msg = slog.LevelDebug, "query"
+	default:
+		return
+	}
+	if !slog.Default().Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	slog.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter SQLに値を埋め込まずにプレースホルダーのまま出力させる
+func (l *GORMLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
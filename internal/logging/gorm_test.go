@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"challecara2025-back/internal/config"
+	"challecara2025-back/internal/database"
+	"challecara2025-back/internal/migrations"
+	"challecara2025-back/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestGORMLoggerKeepsValuesOutOfSQL(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	if _, err := Setup(config.Log{Level: "debug", Format: "json"}, &buf); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+
+	db, err := database.OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := migrations.New(db).Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	const content = "誰にも見せたくない本文"
+	book := models.Book{ID: uuid.New(), Title: "Book", Status: models.BookStatusDraft}
+	episode := models.Episode{ID: uuid.New(), BookID: book.ID, Title: "Episode", EpisodeNo: 1,
+		Status: models.EpisodeStatusDraft, Content: content}
+	if err := db.Create(&book).Error; err != nil {
+		t.Fatalf("insert book: %v", err)
+	}
+	buf.Reset()
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	logged := db.Session(&gorm.Session{Logger: NewGORMLogger(time.Second)}).WithContext(ctx)
+	if err := logged.Create(&episode).Error; err != nil {
+		t.Fatalf("insert episode: %v", err)
+	}
+
+	var found bool
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("decode log line %q: %v", line, err)
+		}
+		sql, _ := record["sql"].(string)
+		if !strings.HasPrefix(sql, "INSERT INTO") || !strings.Contains(sql, "episodes") {
+			continue
+		}
+		found = true
+		if record["level"] != "DEBUG" || record["msg"] != "query" {
+			t.Errorf("level = %v, msg = %v, want DEBUG query", record["level"], record["msg"])
+		}
+		if !strings.Contains(sql, "?") {
+			t.Errorf("sql = %q, want placeholders", sql)
+		}
+		if record["request_id"] != "req-123" {
+			t.Errorf("request_id = %v, want req-123", record["request_id"])
+		}
+	}
+	if !found {
+		t.Fatalf("no query log for the episode insert in %s", buf.String())
+	}
+	if strings.Contains(buf.String(), content) {
+		t.Errorf("log contains the episode content: %s", buf.String())
+	}
+}
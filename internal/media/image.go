@@ -0,0 +1,119 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif" // GIFのデコーダーを登録
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// MaxImageSize アップロードできる画像の最大サイズ
+	MaxImageSize = 5 << 20
+	// maxImagePixels 展開後に極端に大きくなる画像を拒否するための画素数の上限
+	maxImagePixels = 40_000_000
+)
+
+var (
+	ErrUnsupportedImageType = errors.New("unsupported image type")
+	ErrImageTooLarge        = errors.New("image is too large")
+)
+
+// Image 検証済みの画像
+type Image struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+	decoded     image.Image
+}
+
+// DecodeImage 内容から画像の種類を判定し、JPEG・PNG・GIFのみを受け付ける
+func DecodeImage(data []byte) (*Image, error) {
+	if len(data) > MaxImageSize {
+		return nil, ErrImageTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, ErrUnsupportedImageType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImageType
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImageType
+	}
+
+	return &Image{
+		Data:        data,
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+		decoded:     decoded,
+	}, nil
+}
+
+// Thumbnail 幅がmaxWidth以下になるよう縮小した画像を返す（GIFはPNGとして出力する）
+func (img *Image) Thumbnail(maxWidth int) (*Image, error) {
+	width, height := img.Width, img.Height
+	if width > maxWidth {
+		height = height * maxWidth / width
+		width = maxWidth
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img.decoded, img.decoded.Bounds(), draw.Over, nil)
+
+	var buf bytes.Buffer
+	contentType := img.ContentType
+	switch contentType {
+	case "image/jpeg":
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+	default:
+		contentType = "image/png"
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Image{
+		Data:        buf.Bytes(),
+		ContentType: contentType,
+		Width:       width,
+		Height:      height,
+		decoded:     dst,
+	}, nil
+}
+
+// Extension Content-Typeに対応する拡張子
+func Extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	}
+	return ""
+}
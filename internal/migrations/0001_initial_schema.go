@@ -0,0 +1,294 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 0001 マイグレーション導入時点のスキーマ。
+// 以前AutoMigrateで作成したデータベースにもそのまま適用できるよう、AutoMigrateで差分だけを作成する。
+// モデルが変わってもこのマイグレーションの結果が変わらないよう、当時の定義をここに固定している。
+
+type v1Series struct {
+	ID          uuid.UUID    `gorm:"type:char(36);primarykey"`
+	Title       string       `gorm:"size:255;not null"`
+	Description string       `gorm:"type:text"`
+	AuthorID    uuid.UUID    `gorm:"type:char(36)"`
+	Books       []v1Book     `gorm:"foreignKey:SeriesID"`
+	Materials   []v1Material `gorm:"foreignKey:SeriesID"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Series) TableName() string { return "series" }
+
+type v1Book struct {
+	ID          uuid.UUID `gorm:"type:char(36);primarykey"`
+	Title       string    `gorm:"size:255;not null"`
+	Description string    `gorm:"type:text"`
+	AuthorID    uuid.UUID `gorm:"type:char(36)"`
+	CoverImage  string    `gorm:"size:500"`
+	CoverThumb  string    `gorm:"size:500"`
+	CoverKey    string    `gorm:"size:500"`
+	ThumbKey    string    `gorm:"size:500"`
+	Genre       string    `gorm:"size:100"`
+	Status      string    `gorm:"size:50;default:'draft'"`
+	PublishedAt *time.Time
+	CompletedAt *time.Time
+	SeriesID    *uuid.UUID   `gorm:"type:char(36);index"`
+	SeriesOrder int          `gorm:"default:0"`
+	Episodes    []v1Episode  `gorm:"foreignKey:BookID"`
+	Materials   []v1Material `gorm:"foreignKey:BookID"`
+	Stats       *v1BookStats `gorm:"foreignKey:BookID"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Book) TableName() string { return "books" }
+
+type v1Episode struct {
+	ID          uuid.UUID  `gorm:"type:char(36);primarykey"`
+	BookID      uuid.UUID  `gorm:"type:char(36);not null;index"`
+	Title       string     `gorm:"size:255;not null"`
+	Content     string     `gorm:"not null"`
+	EpisodeNo   int        `gorm:"not null"`
+	Status      string     `gorm:"size:50;default:'draft';index:idx_episodes_status_publish_at"`
+	PublishAt   *time.Time `gorm:"index:idx_episodes_status_publish_at"`
+	PublishedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Episode) TableName() string { return "episodes" }
+
+type v1BookImage struct {
+	ID          uuid.UUID `gorm:"type:char(36);primarykey"`
+	BookID      uuid.UUID `gorm:"type:char(36);not null;index"`
+	ContentType string    `gorm:"size:100;not null"`
+	Width       int
+	Height      int
+	Size        int64  `gorm:"not null"`
+	URL         string `gorm:"size:500"`
+	StorageKey  string `gorm:"size:500;not null"`
+	CreatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1BookImage) TableName() string { return "book_images" }
+
+type v1Material struct {
+	ID        uuid.UUID  `gorm:"type:char(36);primarykey"`
+	BookID    *uuid.UUID `gorm:"type:char(36);index"`
+	SeriesID  *uuid.UUID `gorm:"type:char(36);index"`
+	AuthorID  *uuid.UUID `gorm:"type:char(36);index"`
+	Title     string     `gorm:"size:255;not null"`
+	Content   string     `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Material) TableName() string { return "materials" }
+
+type v1BookMaterial struct {
+	BookID     uuid.UUID `gorm:"type:char(36);primaryKey"`
+	MaterialID uuid.UUID `gorm:"type:char(36);primaryKey;index"`
+	CreatedAt  time.Time
+}
+
+func (v1BookMaterial) TableName() string { return "book_materials" }
+
+type v1Attachment struct {
+	ID          uuid.UUID `gorm:"type:char(36);primarykey"`
+	MaterialID  uuid.UUID `gorm:"type:char(36);not null;index"`
+	AuthorID    uuid.UUID `gorm:"type:char(36);not null;index"`
+	FileName    string    `gorm:"size:255;not null"`
+	ContentType string    `gorm:"size:100;not null"`
+	Size        int64     `gorm:"not null"`
+	StorageKey  string    `gorm:"size:500;not null"`
+	CreatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Attachment) TableName() string { return "attachments" }
+
+type v1Bookmark struct {
+	ID             uuid.UUID `gorm:"type:char(36);primarykey"`
+	UserID         uuid.UUID `gorm:"type:char(36);not null;index"`
+	BookID         uuid.UUID `gorm:"type:char(36);not null;index"`
+	EpisodeID      uuid.UUID `gorm:"type:char(36);not null"`
+	ScrollPosition float64   `gorm:"default:0"`
+	Note           string    `gorm:"size:500"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Bookmark) TableName() string { return "bookmarks" }
+
+type v1ReadingProgress struct {
+	UserID         uuid.UUID `gorm:"type:char(36);primaryKey"`
+	BookID         uuid.UUID `gorm:"type:char(36);primaryKey"`
+	EpisodeID      uuid.UUID `gorm:"type:char(36);not null"`
+	EpisodeNo      int       `gorm:"not null"`
+	ScrollPosition float64   `gorm:"default:0"`
+	UpdatedAt      time.Time `gorm:"index"`
+}
+
+func (v1ReadingProgress) TableName() string { return "reading_progresses" }
+
+type v1Comment struct {
+	ID            uuid.UUID   `gorm:"type:char(36);primarykey"`
+	EpisodeID     uuid.UUID   `gorm:"type:char(36);not null;index"`
+	BookID        uuid.UUID   `gorm:"type:char(36);not null;index"`
+	UserID        uuid.UUID   `gorm:"type:char(36);not null;index"`
+	ParentID      *uuid.UUID  `gorm:"type:char(36);index"`
+	Body          string      `gorm:"type:text;not null"`
+	IsAuthorReply bool        `gorm:"default:false"`
+	Hidden        bool        `gorm:"default:false"`
+	ReportCount   int         `gorm:"default:0"`
+	Replies       []v1Comment `gorm:"foreignKey:ParentID"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Comment) TableName() string { return "comments" }
+
+type v1CommentReport struct {
+	ID        uuid.UUID `gorm:"type:char(36);primarykey"`
+	CommentID uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_comment_reports_comment_user"`
+	UserID    uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_comment_reports_comment_user"`
+	Reason    string    `gorm:"size:500"`
+	CreatedAt time.Time
+}
+
+func (v1CommentReport) TableName() string { return "comment_reports" }
+
+type v1Reaction struct {
+	UserID     uuid.UUID `gorm:"type:char(36);primaryKey"`
+	TargetType string    `gorm:"size:20;primaryKey"`
+	TargetID   uuid.UUID `gorm:"type:char(36);primaryKey;index"`
+	Kind       string    `gorm:"size:20;primaryKey"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+func (v1Reaction) TableName() string { return "reactions" }
+
+type v1Follow struct {
+	FollowerID uuid.UUID `gorm:"type:char(36);primaryKey"`
+	AuthorID   uuid.UUID `gorm:"type:char(36);primaryKey;index"`
+	CreatedAt  time.Time
+}
+
+func (v1Follow) TableName() string { return "follows" }
+
+type v1BookStats struct {
+	BookID        uuid.UUID `gorm:"type:char(36);primaryKey"`
+	LikeCount     int64     `gorm:"not null;default:0"`
+	FavoriteCount int64     `gorm:"not null;default:0"`
+	ViewCount     int64     `gorm:"not null;default:0"`
+	UpdatedAt     time.Time
+}
+
+func (v1BookStats) TableName() string { return "book_stats" }
+
+type v1EpisodeStats struct {
+	EpisodeID     uuid.UUID `gorm:"type:char(36);primaryKey"`
+	BookID        uuid.UUID `gorm:"type:char(36);not null;index"`
+	LikeCount     int64     `gorm:"not null;default:0"`
+	FavoriteCount int64     `gorm:"not null;default:0"`
+	UpdatedAt     time.Time
+}
+
+func (v1EpisodeStats) TableName() string { return "episode_stats" }
+
+type v1AuthorStats struct {
+	AuthorID      uuid.UUID `gorm:"type:char(36);primaryKey"`
+	FollowerCount int64     `gorm:"not null;default:0"`
+	UpdatedAt     time.Time
+}
+
+func (v1AuthorStats) TableName() string { return "author_stats" }
+
+type v1EpisodeView struct {
+	ID        uuid.UUID `gorm:"type:char(36);primarykey"`
+	EpisodeID uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_episode_views_dedup"`
+	BookID    uuid.UUID `gorm:"type:char(36);not null;index"`
+	ViewerKey string    `gorm:"size:64;not null;uniqueIndex:idx_episode_views_dedup;index"`
+	Day       time.Time `gorm:"not null;uniqueIndex:idx_episode_views_dedup;index"`
+	CreatedAt time.Time
+}
+
+func (v1EpisodeView) TableName() string { return "episode_views" }
+
+type v1EpisodeViewDaily struct {
+	EpisodeID uuid.UUID `gorm:"type:char(36);primaryKey"`
+	Day       time.Time `gorm:"primaryKey;index"`
+	BookID    uuid.UUID `gorm:"type:char(36);not null;index"`
+	Views     int64     `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+func (v1EpisodeViewDaily) TableName() string { return "episode_view_dailies" }
+
+type v1Ranking struct {
+	Period      string    `gorm:"size:20;primaryKey"`
+	Genre       string    `gorm:"size:100;primaryKey"`
+	Rank        int       `gorm:"column:position;primaryKey;autoIncrement:false"`
+	BookID      uuid.UUID `gorm:"type:char(36);not null;index"`
+	Score       float64   `gorm:"not null"`
+	Views       int64     `gorm:"not null"`
+	Favorites   int64     `gorm:"not null"`
+	NewEpisodes int64     `gorm:"not null"`
+	ComputedAt  time.Time
+}
+
+func (v1Ranking) TableName() string { return "rankings" }
+
+// initialSchemaModels 依存関係の順（参照される側が先）に並べる
+var initialSchemaModels = []interface{}{
+	&v1Series{},
+	&v1Book{},
+	&v1Episode{},
+	&v1BookImage{},
+	&v1Material{},
+	&v1BookMaterial{},
+	&v1Attachment{},
+	&v1Bookmark{},
+	&v1ReadingProgress{},
+	&v1Comment{},
+	&v1CommentReport{},
+	&v1Reaction{},
+	&v1Follow{},
+	&v1BookStats{},
+	&v1EpisodeStats{},
+	&v1AuthorStats{},
+	&v1EpisodeView{},
+	&v1EpisodeViewDaily{},
+	&v1Ranking{},
+}
+
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(initialSchemaModels...)
+		},
+		Down: func(tx *gorm.DB) error {
+			// 参照する側から削除する
+			for i := len(initialSchemaModels) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(initialSchemaModels[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
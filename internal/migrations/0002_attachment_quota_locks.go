@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 0002 添付ファイルの容量制限の確認に使う作者ごとのロック行（以前は作者の集計行をロックしていた）
+
+type v2AttachmentQuotaLock struct {
+	AuthorID  uuid.UUID `gorm:"type:char(36);primaryKey"`
+	CreatedAt time.Time
+}
+
+func (v2AttachmentQuotaLock) TableName() string { return "attachment_quota_locks" }
+
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "attachment_quota_locks",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&v2AttachmentQuotaLock{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v2AttachmentQuotaLock{})
+		},
+	})
+}
@@ -0,0 +1,357 @@
+// Package migrations 番号付きのスキーママイグレーションを順番に適用・ロールバックする
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// lockTimeout 他のレプリカがロックを保持している場合に待つ時間
+	lockTimeout = 2 * time.Minute
+	// staleLockAfter 異常終了などで更新されなくなったロックを無効とみなすまでの時間
+	staleLockAfter   = 15 * time.Minute
+	lockPollInterval = time.Second
+)
+
+// heartbeatInterval ロックを保持している間、ロックの時刻を更新する間隔（staleLockAfterより十分短くする）
+var heartbeatInterval = time.Minute
+
+var (
+	ErrLocked       = errors.New("migrations are locked by another process")
+	ErrLockLost     = errors.New("migration lock was taken over by another process")
+	ErrIrreversible = errors.New("migration cannot be rolled back")
+)
+
+// Migration 1つのスキーマ変更。Versionの昇順に適用する
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// Status マイグレーションの適用状況
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// schemaMigration 適用済みのマイグレーションを記録する
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string { return "schema_migrations" }
+
+// migrationLock 複数のレプリカが同時にマイグレーションしないためのロック（ID=1の行を保持している間がロック中）
+type migrationLock struct {
+	ID       int       `gorm:"primaryKey;autoIncrement:false"`
+	Owner    string    `gorm:"size:64;not null"`
+	LockedAt time.Time `gorm:"not null"`
+}
+
+func (migrationLock) TableName() string { return "schema_migrations_lock" }
+
+// registry 登録済みのマイグレーション（各ファイルのinitで登録する）
+var registry []Migration
+
+func register(m Migration) {
+	registry = append(registry, m)
+}
+
+// All 登録済みのマイグレーションをVersion順に返す
+func All() []Migration {
+	migrations := append([]Migration(nil), registry...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations
+}
+
+// Migrator データベースにマイグレーションを適用する
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func New(db *gorm.DB) *Migrator {
+	return &Migrator{db: db, migrations: All()}
+}
+
+// Up 未適用のマイグレーションをすべて適用し、適用したものを返す
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		versions, err := m.appliedVersions(db)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := migration.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			slog.InfoContext(ctx, "Applied migration", "version", migration.Version, "name", migration.Name)
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down 適用済みのマイグレーションを新しい順にsteps件ロールバックし、ロールバックしたものを返す
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var rolledBack []Migration
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		versions, err := m.appliedVersions(db)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, ErrIrreversible)
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := migration.Down(tx); err != nil {
+					return err
+				}
+				return tx.Where("version = ?", migration.Version).Delete(&schemaMigration{}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("rollback of %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			slog.InfoContext(ctx, "Rolled back migration", "version", migration.Version, "name", migration.Name)
+			rolledBack = append(rolledBack, migration)
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status すべてのマイグレーションの適用状況をVersion順に返す
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	db := m.db.WithContext(ctx)
+	if err := ensureTables(ctx, db); err != nil {
+		return nil, err
+	}
+	versions, err := m.appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := versions[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &record.AppliedAt
+			delete(versions, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	// このバイナリが知らないマイグレーション（新しいバージョンで適用されたもの）も表示する
+	for _, record := range versions {
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, Status{Version: record.Version, Name: record.Name, Applied: true, AppliedAt: &appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending 未適用のマイグレーションを返す。
+// ヘルスチェックから頻繁に呼ばれるため、Statusと違い管理用のテーブルの作成やロックは行わない
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return append([]Migration(nil), m.migrations...), nil
+	}
+	versions, err := m.appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := versions[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) appliedVersions(db *gorm.DB) (map[int64]schemaMigration, error) {
+	var records []schemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	versions := make(map[int64]schemaMigration, len(records))
+	for _, record := range records {
+		versions[record.Version] = record
+	}
+	return versions, nil
+}
+
+// withLock ロックを取得してからfnを実行する。
+// 実行中はロックの時刻を定期的に更新し、時間のかかるマイグレーションが古いロックとして他のプロセスに奪われないようにする。
+// それでもロックを奪われた場合はfnに渡したcontextをキャンセルし、ErrLockLostを返す
+func (m *Migrator) withLock(ctx context.Context, fn func(db *gorm.DB) error) error {
+	db := m.db.WithContext(ctx)
+	if err := ensureTables(ctx, db); err != nil {
+		return fmt.Errorf("failed to prepare migration tables: %w", err)
+	}
+
+	owner, err := lockOwner()
+	if err != nil {
+		return err
+	}
+	if err := acquireLock(ctx, db, owner); err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	heartbeat := make(chan struct{})
+	go func() {
+		defer close(heartbeat)
+		m.keepLock(runCtx, owner, cancel)
+	}()
+	defer func() {
+		cancel(nil)
+		<-heartbeat
+		// 呼び出し元のcontextがキャンセルされていてもロックは解放する
+		if err := m.db.Where("id = ? AND owner = ?", 1, owner).Delete(&migrationLock{}).Error; err != nil {
+			slog.Error("Failed to release migration lock", "error", err)
+		}
+	}()
+
+	if err := fn(m.db.WithContext(runCtx)); err != nil {
+		if cause := context.Cause(runCtx); errors.Is(cause, ErrLockLost) {
+			return fmt.Errorf("%w: %v", cause, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// keepLock ctxが終わるまでheartbeatIntervalごとにロックの時刻を更新する。ロックを奪われた場合はErrLockLostでcancelする
+func (m *Migrator) keepLock(ctx context.Context, owner string, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := refreshLock(m.db.WithContext(ctx), owner)
+		switch {
+		case errors.Is(err, ErrLockLost):
+			slog.ErrorContext(ctx, "Migration lock was taken over", "owner", owner)
+			cancel(err)
+			return
+		case err != nil && ctx.Err() == nil:
+			slog.WarnContext(ctx, "Failed to refresh migration lock", "error", err)
+		}
+	}
+}
+
+// refreshLock 保持しているロックの時刻を現在時刻にする。ロックが他のプロセスのものになっていればErrLockLost
+func refreshLock(db *gorm.DB, owner string) error {
+	result := db.Model(&migrationLock{}).Where("id = ? AND owner = ?", 1, owner).Update("locked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// ensureTables 管理用のテーブルを作成する。
+// 同時に起動したレプリカと作成が競合して失敗することがあるため、少し待ってから作り直す
+func ensureTables(ctx context.Context, db *gorm.DB) error {
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		if err = db.AutoMigrate(&schemaMigration{}, &migrationLock{}); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+	return err
+}
+
+func acquireLock(ctx context.Context, db *gorm.DB, owner string) error {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		createErr := db.Create(&migrationLock{ID: 1, Owner: owner, LockedAt: time.Now()}).Error
+		if createErr == nil {
+			return nil
+		}
+
+		// 作成に失敗した場合は他のプロセスがロックを保持しているか確認する
+		var held migrationLock
+		if err := db.Where("id = ?", 1).First(&held).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("failed to acquire migration lock: %w", createErr)
+			}
+			return err
+		}
+		if time.Since(held.LockedAt) > staleLockAfter {
+			slog.WarnContext(ctx, "Removing stale migration lock", "owner", held.Owner, "locked_at", held.LockedAt)
+			if err := db.Where("id = ? AND owner = ?", 1, held.Owner).Delete(&migrationLock{}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w (held by %s)", ErrLocked, held.Owner)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// lockOwner ロックの保持者を識別する文字列（ホスト名とランダムなID）
+func lockOwner() (string, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	host, _ := os.Hostname()
+	owner := host + "/" + id.String()
+	if len(owner) > 64 {
+		owner = owner[len(owner)-64:]
+	}
+	return owner, nil
+}
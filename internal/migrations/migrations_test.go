@@ -0,0 +1,96 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"challecara2025-back/internal/database"
+
+	"gorm.io/gorm"
+)
+
+func newTestMigrator(t *testing.T) *Migrator {
+	t.Helper()
+	db, err := database.OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return New(db)
+}
+
+// shortHeartbeat テストの間だけheartbeatIntervalを短くする
+func shortHeartbeat(t *testing.T) {
+	interval := heartbeatInterval
+	heartbeatInterval = 10 * time.Millisecond
+	t.Cleanup(func() { heartbeatInterval = interval })
+}
+
+func currentLock(t *testing.T, db *gorm.DB) migrationLock {
+	t.Helper()
+	var lock migrationLock
+	if err := db.First(&lock, 1).Error; err != nil {
+		t.Fatalf("load lock: %v", err)
+	}
+	return lock
+}
+
+func TestWithLockRefreshesLock(t *testing.T) {
+	shortHeartbeat(t)
+	m := newTestMigrator(t)
+
+	err := m.withLock(context.Background(), func(db *gorm.DB) error {
+		acquired := currentLock(t, db).LockedAt
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if currentLock(t, db).LockedAt.After(acquired) {
+				return nil
+			}
+			time.Sleep(heartbeatInterval)
+		}
+		t.Error("locked_at was not refreshed while the lock was held")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withLock: %v", err)
+	}
+
+	var count int64
+	m.db.Model(&migrationLock{}).Count(&count)
+	if count != 0 {
+		t.Errorf("lock rows after release = %d, want 0", count)
+	}
+}
+
+func TestWithLockCancelsWhenLockIsTakenOver(t *testing.T) {
+	shortHeartbeat(t)
+	m := newTestMigrator(t)
+
+	err := m.withLock(context.Background(), func(db *gorm.DB) error {
+		// 古いロックとして他のプロセスに奪われた状態にする
+		if err := db.Model(&migrationLock{}).Where("id = ?", 1).Update("owner", "other").Error; err != nil {
+			t.Fatalf("take over lock: %v", err)
+		}
+		select {
+		case <-db.Statement.Context.Done():
+			return db.Statement.Context.Err()
+		case <-time.After(5 * time.Second):
+			t.Error("context was not canceled after the lock was taken over")
+			return nil
+		}
+	})
+	if !errors.Is(err, ErrLockLost) {
+		t.Fatalf("withLock: err = %v, want ErrLockLost", err)
+	}
+
+	// 奪ったプロセスのロックは解放しない
+	if lock := currentLock(t, m.db); lock.Owner != "other" {
+		t.Errorf("lock owner = %q, want %q", lock.Owner, "other")
+	}
+}
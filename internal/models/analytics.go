@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EpisodeView 読者（またはセッション）ごと・日ごとに1件だけ記録するエピソードの閲覧
+type EpisodeView struct {
+	ID        uuid.UUID `gorm:"type:char(36);primarykey" json:"id"`
+	EpisodeID uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_episode_views_dedup" json:"episode_id"`
+	BookID    uuid.UUID `gorm:"type:char(36);not null;index" json:"book_id"`
+	ViewerKey string    `gorm:"size:64;not null;uniqueIndex:idx_episode_views_dedup;index" json:"-"` // 利用者IDまたはセッションのハッシュ
+	Day       time.Time `gorm:"not null;uniqueIndex:idx_episode_views_dedup;index" json:"day"`
+	CreatedAt time.Time `json:"created_at"`
+}
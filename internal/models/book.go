@@ -1,23 +1,125 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Bookの公開ステータス
+const (
+	BookStatusDraft     = "draft"
+	BookStatusPublished = "published"
+	BookStatusHiatus    = "hiatus"
+	BookStatusCompleted = "completed"
+)
+
+var (
+	ErrInvalidBookStatus       = errors.New("invalid book status")
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+)
+
+// bookStatusTransitions 各ステータスから遷移できるステータス
+var bookStatusTransitions = map[string][]string{
+	BookStatusDraft:     {BookStatusPublished},
+	BookStatusPublished: {BookStatusHiatus, BookStatusCompleted, BookStatusDraft},
+	BookStatusHiatus:    {BookStatusPublished, BookStatusCompleted, BookStatusDraft},
+	BookStatusCompleted: {BookStatusPublished, BookStatusDraft},
+}
+
 type Book struct {
 	ID          uuid.UUID      `gorm:"type:char(36);primarykey" json:"id"`
 	Title       string         `gorm:"size:255;not null" json:"title"`
 	Description string         `gorm:"type:text" json:"description"`
 	AuthorID    uuid.UUID      `gorm:"type:char(36)" json:"author_id"` // 認証実装時に使用予定
 	CoverImage  string         `gorm:"size:500" json:"cover_image,omitempty"`
+	CoverThumb  string         `gorm:"size:500" json:"cover_thumbnail,omitempty"`
+	CoverKey    string         `gorm:"size:500" json:"-"` // ストレージ上の表紙画像・サムネイルのキー
+	ThumbKey    string         `gorm:"size:500" json:"-"`
 	Genre       string         `gorm:"size:100" json:"genre"`
-	Status      string         `gorm:"size:50;default:'draft'" json:"status"` // draft, published, completed
+	Status      string         `gorm:"size:50;default:'draft'" json:"status"` // draft, published, hiatus, completed
+	PublishedAt *time.Time     `json:"published_at,omitempty"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+	SeriesID    *uuid.UUID     `gorm:"type:char(36);index" json:"series_id,omitempty"`
+	SeriesOrder int            `gorm:"default:0" json:"series_order"` // シリーズ内の巻数（1始まり）
 	Episodes    []Episode      `gorm:"foreignKey:BookID" json:"episodes,omitempty"`
 	Materials   []Material     `gorm:"foreignKey:BookID" json:"materials,omitempty"`
+	Stats       *BookStats     `gorm:"foreignKey:BookID" json:"stats,omitempty"`
+	AuthorStats *AuthorStats   `gorm:"-" json:"author_stats,omitempty"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
-}
\ No newline at end of file
+}
+
+// PublicBookStatuses 読者に公開されるステータス
+var PublicBookStatuses = []string{BookStatusPublished, BookStatusHiatus, BookStatusCompleted}
+
+// PublicBooks 読者に公開されているBookに絞り込むスコープ
+func PublicBooks(db *gorm.DB) *gorm.DB {
+	return db.Where("status IN ?", PublicBookStatuses)
+}
+
+// IsValidBookStatus 定義済みのステータスかどうか
+func IsValidBookStatus(status string) bool {
+	_, ok := bookStatusTransitions[status]
+	return ok
+}
+
+// CanTransitionBookStatus fromからtoへ遷移できるかどうか（同じステータスへの遷移は許可）
+func CanTransitionBookStatus(from, to string) bool {
+	if from == to {
+		return IsValidBookStatus(to)
+	}
+	for _, next := range bookStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextBookStatuses fromから遷移できるステータスの一覧
+func NextBookStatuses(from string) []string {
+	return append([]string(nil), bookStatusTransitions[from]...)
+}
+
+// TransitionStatus ステータスを遷移させ、公開日時・完結日時を記録する
+func (b *Book) TransitionStatus(to string, now time.Time) error {
+	if !IsValidBookStatus(to) {
+		return ErrInvalidBookStatus
+	}
+	from := b.Status
+	if from == "" {
+		from = BookStatusDraft
+	}
+	if !CanTransitionBookStatus(from, to) {
+		return ErrInvalidStatusTransition
+	}
+	if from == to {
+		b.Status = to
+		return nil
+	}
+
+	switch to {
+	case BookStatusDraft:
+		// 非公開に戻した場合は公開日時をリセット
+		b.PublishedAt = nil
+		b.CompletedAt = nil
+	case BookStatusPublished:
+		if b.PublishedAt == nil {
+			b.PublishedAt = &now
+		}
+		b.CompletedAt = nil
+	case BookStatusHiatus:
+		b.CompletedAt = nil
+	case BookStatusCompleted:
+		if b.PublishedAt == nil {
+			b.PublishedAt = &now
+		}
+		b.CompletedAt = &now
+	}
+	b.Status = to
+	return nil
+}
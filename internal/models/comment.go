@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Comment エピソードへの読者コメント（ParentIDが設定されている場合は返信）
+type Comment struct {
+	ID            uuid.UUID      `gorm:"type:char(36);primarykey" json:"id"`
+	EpisodeID     uuid.UUID      `gorm:"type:char(36);not null;index" json:"episode_id"`
+	BookID        uuid.UUID      `gorm:"type:char(36);not null;index" json:"book_id"`
+	UserID        uuid.UUID      `gorm:"type:char(36);not null;index" json:"user_id"`
+	ParentID      *uuid.UUID     `gorm:"type:char(36);index" json:"parent_id,omitempty"`
+	Body          string         `gorm:"type:text;not null" json:"body"`
+	IsAuthorReply bool           `gorm:"default:false" json:"is_author_reply"` // Bookの作者による投稿
+	Hidden        bool           `gorm:"default:false" json:"hidden"`          // 作者によって非表示にされた
+	ReportCount   int            `gorm:"default:0" json:"report_count"`
+	Replies       []Comment      `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	Deleted       bool           `gorm:"-" json:"deleted,omitempty"`
+}
+
+// CommentReport コメントの通報（同じ利用者は1コメントにつき1回まで）
+type CommentReport struct {
+	ID        uuid.UUID `gorm:"type:char(36);primarykey" json:"id"`
+	CommentID uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_comment_reports_comment_user" json:"comment_id"`
+	UserID    uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_comment_reports_comment_user" json:"user_id"`
+	Reason    string    `gorm:"size:500" json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// リアクションの種類と対象
+const (
+	ReactionLike     = "like"
+	ReactionFavorite = "favorite"
+
+	ReactionTargetBook    = "book"
+	ReactionTargetEpisode = "episode"
+)
+
+// Reaction 読者によるいいね・お気に入り（利用者・対象・種類ごとに1件）
+type Reaction struct {
+	UserID     uuid.UUID `gorm:"type:char(36);primaryKey" json:"user_id"`
+	TargetType string    `gorm:"size:20;primaryKey" json:"target_type"` // book, episode
+	TargetID   uuid.UUID `gorm:"type:char(36);primaryKey;index" json:"target_id"`
+	Kind       string    `gorm:"size:20;primaryKey" json:"kind"` // like, favorite
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// Follow 読者による作者のフォロー
+type Follow struct {
+	FollowerID uuid.UUID `gorm:"type:char(36);primaryKey" json:"follower_id"`
+	AuthorID   uuid.UUID `gorm:"type:char(36);primaryKey;index" json:"author_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// BookStats Bookごとの集計値（リクエストのたびにCOUNTしないようにカウンタとして保持）
+type BookStats struct {
+	BookID        uuid.UUID `gorm:"type:char(36);primaryKey" json:"book_id"`
+	LikeCount     int64     `gorm:"not null;default:0" json:"like_count"`
+	FavoriteCount int64     `gorm:"not null;default:0" json:"favorite_count"`
+	ViewCount     int64     `gorm:"not null;default:0" json:"view_count"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// EpisodeStats エピソードごとの集計値
+type EpisodeStats struct {
+	EpisodeID     uuid.UUID `gorm:"type:char(36);primaryKey" json:"episode_id"`
+	BookID        uuid.UUID `gorm:"type:char(36);not null;index" json:"book_id"`
+	LikeCount     int64     `gorm:"not null;default:0" json:"like_count"`
+	FavoriteCount int64     `gorm:"not null;default:0" json:"favorite_count"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// AuthorStats 作者ごとの集計値
+type AuthorStats struct {
+	AuthorID      uuid.UUID `gorm:"type:char(36);primaryKey" json:"author_id"`
+	FollowerCount int64     `gorm:"not null;default:0" json:"follower_count"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -1,19 +1,64 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Episodeの公開ステータス
+const (
+	EpisodeStatusDraft     = "draft"
+	EpisodeStatusScheduled = "scheduled"
+	EpisodeStatusPublished = "published"
+)
+
+var (
+	ErrInvalidEpisodeStatus = errors.New("invalid episode status")
+	ErrPublishAtRequired    = errors.New("publish_at is required for scheduled episodes")
+)
+
 type Episode struct {
-	ID        uuid.UUID      `gorm:"type:char(36);primarykey" json:"id"`
-	BookID    uuid.UUID      `gorm:"type:char(36);not null;index" json:"book_id"`
-	Title     string         `gorm:"size:255;not null" json:"title"`
-	Content   string         `gorm:"type:longtext;not null" json:"content"`
-	EpisodeNo int            `gorm:"not null" json:"episode_no"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID          uuid.UUID      `gorm:"type:char(36);primarykey" json:"id"`
+	BookID      uuid.UUID      `gorm:"type:char(36);not null;index" json:"book_id"`
+	Title       string         `gorm:"size:255;not null" json:"title"`
+	Content     string         `gorm:"not null" json:"content"` // サイズ未指定のためMySQLではlongtext、PostgreSQL・SQLiteではtext
+	EpisodeNo   int            `gorm:"not null" json:"episode_no"`
+	Status      string         `gorm:"size:50;default:'draft';index:idx_episodes_status_publish_at" json:"status"` // draft, scheduled, published
+	PublishAt   *time.Time     `gorm:"index:idx_episodes_status_publish_at" json:"publish_at,omitempty"`           // 予約公開日時
+	PublishedAt *time.Time     `json:"published_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// ApplyStatus 公開ステータスを設定する。scheduledの場合はpublishAtが必須
+func (e *Episode) ApplyStatus(status string, publishAt *time.Time, now time.Time) error {
+	switch status {
+	case EpisodeStatusDraft:
+		e.PublishAt = nil
+		e.PublishedAt = nil
+	case EpisodeStatusScheduled:
+		if publishAt == nil {
+			return ErrPublishAtRequired
+		}
+		e.PublishAt = publishAt
+		e.PublishedAt = nil
+	case EpisodeStatusPublished:
+		if e.PublishedAt == nil {
+			e.PublishedAt = &now
+		}
+		e.PublishAt = nil
+	default:
+		return ErrInvalidEpisodeStatus
+	}
+	e.Status = status
+	return nil
+}
+
+// PublishedEpisodes 公開済みのエピソードに絞り込むスコープ
+func PublishedEpisodes(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", EpisodeStatusPublished)
 }
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BookImage エピソード本文に挿絵として埋め込む画像
+type BookImage struct {
+	ID          uuid.UUID      `gorm:"type:char(36);primarykey" json:"id"`
+	BookID      uuid.UUID      `gorm:"type:char(36);not null;index" json:"book_id"`
+	ContentType string         `gorm:"size:100;not null" json:"content_type"`
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	Size        int64          `gorm:"not null" json:"size"`
+	URL         string         `gorm:"size:500" json:"url"`
+	StorageKey  string         `gorm:"size:500;not null" json:"-"`
+	Token       string         `gorm:"-" json:"token"` // 本文に埋め込むためのマークアップ
+	CreatedAt   time.Time      `json:"created_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -9,10 +9,38 @@ import (
 
 type Material struct {
 	ID        uuid.UUID      `gorm:"type:char(36);primarykey" json:"id"`
-	BookID    uuid.UUID      `gorm:"type:char(36);not null;index" json:"book_id"`
+	BookID    *uuid.UUID     `gorm:"type:char(36);index" json:"book_id,omitempty"`
+	SeriesID  *uuid.UUID     `gorm:"type:char(36);index" json:"series_id,omitempty"` // シリーズ共有資料の場合に設定
+	AuthorID  *uuid.UUID     `gorm:"type:char(36);index" json:"author_id,omitempty"` // 作者ライブラリの資料の場合に設定
 	Title     string         `gorm:"size:255;not null" json:"title"`
-	Content   string         `gorm:"type:longtext;not null" json:"content"`
+	Content   string         `gorm:"not null" json:"content"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
+
+// BookMaterial 参考資料を他のBookにも共有するための中間テーブル
+type BookMaterial struct {
+	BookID     uuid.UUID `gorm:"type:char(36);primaryKey" json:"book_id"`
+	MaterialID uuid.UUID `gorm:"type:char(36);primaryKey;index" json:"material_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Attachment 参考資料に添付されたファイル（キャラクターの参考画像や地図など）
+type Attachment struct {
+	ID          uuid.UUID      `gorm:"type:char(36);primarykey" json:"id"`
+	MaterialID  uuid.UUID      `gorm:"type:char(36);not null;index" json:"material_id"`
+	AuthorID    uuid.UUID      `gorm:"type:char(36);not null;index" json:"author_id"` // 容量制限の集計に使う所有者
+	FileName    string         `gorm:"size:255;not null" json:"file_name"`
+	ContentType string         `gorm:"size:100;not null" json:"content_type"`
+	Size        int64          `gorm:"not null" json:"size"`
+	StorageKey  string         `gorm:"size:500;not null" json:"-"`
+	CreatedAt   time.Time      `json:"created_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// AttachmentQuotaLock 作者ごとの添付ファイルの使用量の確認と登録を1つずつ行うためのロック行
+type AttachmentQuotaLock struct {
+	AuthorID  uuid.UUID `gorm:"type:char(36);primaryKey" json:"author_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ランキングの集計期間
+const (
+	RankingPeriodDaily   = "daily"
+	RankingPeriodWeekly  = "weekly"
+	RankingPeriodAllTime = "all_time"
+)
+
+// RankingPeriods 集計する期間の一覧
+var RankingPeriods = []string{RankingPeriodDaily, RankingPeriodWeekly, RankingPeriodAllTime}
+
+// EpisodeViewDaily エピソードごと・日ごと（UTC）の閲覧数
+type EpisodeViewDaily struct {
+	EpisodeID uuid.UUID `gorm:"type:char(36);primaryKey" json:"episode_id"`
+	Day       time.Time `gorm:"primaryKey;index" json:"day"`
+	BookID    uuid.UUID `gorm:"type:char(36);not null;index" json:"book_id"`
+	Views     int64     `gorm:"not null;default:0" json:"views"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Ranking 定期ジョブで集計したランキング（Genreが空の場合は全ジャンル）
+type Ranking struct {
+	Period      string    `gorm:"size:20;primaryKey" json:"period"`
+	Genre       string    `gorm:"size:100;primaryKey" json:"genre"`
+	Rank        int       `gorm:"column:position;primaryKey;autoIncrement:false" json:"rank"` // RANKはMySQLの予約語のため列名を変える
+	BookID      uuid.UUID `gorm:"type:char(36);not null;index" json:"book_id"`
+	Score       float64   `gorm:"not null" json:"score"`
+	Views       int64     `gorm:"not null" json:"views"`
+	Favorites   int64     `gorm:"not null" json:"favorites"`
+	NewEpisodes int64     `gorm:"not null" json:"new_episodes"`
+	ComputedAt  time.Time `json:"computed_at"`
+}
+
+// IsValidRankingPeriod 定義済みの集計期間かどうか
+func IsValidRankingPeriod(period string) bool {
+	for _, p := range RankingPeriods {
+		if p == period {
+			return true
+		}
+	}
+	return false
+}
+
+// StartOfDay 日ごとの集計に使うUTCの日付（0時0分）を返す
+func StartOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Bookmark 読者がエピソードに付けたしおり
+type Bookmark struct {
+	ID             uuid.UUID      `gorm:"type:char(36);primarykey" json:"id"`
+	UserID         uuid.UUID      `gorm:"type:char(36);not null;index" json:"user_id"`
+	BookID         uuid.UUID      `gorm:"type:char(36);not null;index" json:"book_id"`
+	EpisodeID      uuid.UUID      `gorm:"type:char(36);not null" json:"episode_id"`
+	ScrollPosition float64        `gorm:"default:0" json:"scroll_position"` // 本文中の位置（0.0〜1.0）
+	Note           string         `gorm:"size:500" json:"note"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// ReadingProgress 読者ごと・Bookごとの最後に読んだ位置
+type ReadingProgress struct {
+	UserID         uuid.UUID `gorm:"type:char(36);primaryKey" json:"user_id"`
+	BookID         uuid.UUID `gorm:"type:char(36);primaryKey" json:"book_id"`
+	EpisodeID      uuid.UUID `gorm:"type:char(36);not null" json:"episode_id"`
+	EpisodeNo      int       `gorm:"not null" json:"episode_no"`
+	ScrollPosition float64   `gorm:"default:0" json:"scroll_position"` // 本文中の位置（0.0〜1.0）
+	UpdatedAt      time.Time `gorm:"index" json:"updated_at"`
+}
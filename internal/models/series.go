@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Series 複数巻にまたがる作品のまとまり
+type Series struct {
+	ID          uuid.UUID      `gorm:"type:char(36);primarykey" json:"id"`
+	Title       string         `gorm:"size:255;not null" json:"title"`
+	Description string         `gorm:"type:text" json:"description"`
+	AuthorID    uuid.UUID      `gorm:"type:char(36)" json:"author_id"`
+	Books       []Book         `gorm:"foreignKey:SeriesID" json:"books,omitempty"`
+	Materials   []Material     `gorm:"foreignKey:SeriesID" json:"materials,omitempty"` // 全巻から参照できる共有資料
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -0,0 +1,192 @@
+package ranking
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// スコアの重み（閲覧1回を1点としたときの重み）
+const (
+	favoriteWeight   = 10
+	newEpisodeWeight = 5
+)
+
+// Limit 期間・ジャンルごとに保持する順位の数
+const Limit = 100
+
+type bookMetrics struct {
+	BookID      uuid.UUID
+	Genre       string
+	Views       int64
+	Favorites   int64
+	NewEpisodes int64
+}
+
+func (m *bookMetrics) score() float64 {
+	return float64(m.Views + m.Favorites*favoriteWeight + m.NewEpisodes*newEpisodeWeight)
+}
+
+// Compute 公開中のBookのランキングを期間・ジャンルごとに集計し、rankingsテーブルを置き換える
+func Compute(ctx context.Context, db *gorm.DB, now time.Time) error {
+	db = db.WithContext(ctx)
+
+	var books []models.Book
+	if err := db.Scopes(models.PublicBooks).Select("id", "genre").Find(&books).Error; err != nil {
+		return fmt.Errorf("failed to fetch books: %w", err)
+	}
+
+	for _, period := range models.RankingPeriods {
+		metrics, err := collect(db, books, since(period, now))
+		if err != nil {
+			return fmt.Errorf("failed to collect %s metrics: %w", period, err)
+		}
+		if err := store(db, period, metrics, now); err != nil {
+			return fmt.Errorf("failed to store %s ranking: %w", period, err)
+		}
+	}
+	return nil
+}
+
+// since 集計期間の開始日時（全期間の場合はゼロ値）。
+// 閲覧数は日ごと（UTC）にしか記録しないため、すべての指標をUTCの暦日で区切る（dailyは当日、weeklyは当日を含む7日間）
+func since(period string, now time.Time) time.Time {
+	switch period {
+	case models.RankingPeriodDaily:
+		return models.StartOfDay(now)
+	case models.RankingPeriodWeekly:
+		return models.StartOfDay(now).AddDate(0, 0, -6)
+	}
+	return time.Time{}
+}
+
+// collect 期間内の閲覧数・お気に入り数・新規エピソード数をBookごとに集計する
+func collect(db *gorm.DB, books []models.Book, from time.Time) ([]*bookMetrics, error) {
+	metrics := make(map[uuid.UUID]*bookMetrics, len(books))
+	for _, book := range books {
+		metrics[book.ID] = &bookMetrics{BookID: book.ID, Genre: book.Genre}
+	}
+
+	type row struct {
+		BookID uuid.UUID
+		Total  int64
+	}
+
+	var views []row
+	viewQuery := db.Model(&models.EpisodeViewDaily{}).Select("book_id, SUM(views) AS total").Group("book_id")
+	if !from.IsZero() {
+		viewQuery = viewQuery.Where("day >= ?", from)
+	}
+	if err := viewQuery.Scan(&views).Error; err != nil {
+		return nil, err
+	}
+	for _, v := range views {
+		if m, ok := metrics[v.BookID]; ok {
+			m.Views = v.Total
+		}
+	}
+
+	var favorites []row
+	favoriteQuery := db.Model(&models.Reaction{}).
+		Select("target_id AS book_id, COUNT(*) AS total").
+		Where("target_type = ? AND kind = ?", models.ReactionTargetBook, models.ReactionFavorite).
+		Group("target_id")
+	if !from.IsZero() {
+		favoriteQuery = favoriteQuery.Where("created_at >= ?", from)
+	}
+	if err := favoriteQuery.Scan(&favorites).Error; err != nil {
+		return nil, err
+	}
+	for _, f := range favorites {
+		if m, ok := metrics[f.BookID]; ok {
+			m.Favorites = f.Total
+		}
+	}
+
+	var episodes []row
+	episodeQuery := db.Model(&models.Episode{}).Scopes(models.PublishedEpisodes).
+		Select("book_id, COUNT(*) AS total").
+		Group("book_id")
+	if !from.IsZero() {
+		episodeQuery = episodeQuery.Where("published_at >= ?", from)
+	}
+	if err := episodeQuery.Scan(&episodes).Error; err != nil {
+		return nil, err
+	}
+	for _, e := range episodes {
+		if m, ok := metrics[e.BookID]; ok {
+			m.NewEpisodes = e.Total
+		}
+	}
+
+	result := make([]*bookMetrics, 0, len(metrics))
+	for _, m := range metrics {
+		result = append(result, m)
+	}
+
+	// スコアが同じ場合もBook IDで並びを固定する
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.score() != b.score() {
+			return a.score() > b.score()
+		}
+		if a.Favorites != b.Favorites {
+			return a.Favorites > b.Favorites
+		}
+		if a.Views != b.Views {
+			return a.Views > b.Views
+		}
+		return a.BookID.String() < b.BookID.String()
+	})
+	return result, nil
+}
+
+// store 全ジャンル・ジャンル別のランキングを作り、期間の既存ランキングと入れ替える
+func store(db *gorm.DB, period string, metrics []*bookMetrics, now time.Time) error {
+	var rankings []models.Ranking
+	counts := map[string]int{}
+
+	for _, m := range metrics {
+		// 何の実績も無いBookは順位に含めない
+		if m.score() == 0 {
+			continue
+		}
+		genres := []string{""}
+		if m.Genre != "" {
+			genres = append(genres, m.Genre)
+		}
+		for _, genre := range genres {
+			if counts[genre] >= Limit {
+				continue
+			}
+			counts[genre]++
+			rankings = append(rankings, models.Ranking{
+				Period:      period,
+				Genre:       genre,
+				Rank:        counts[genre],
+				BookID:      m.BookID,
+				Score:       m.score(),
+				Views:       m.Views,
+				Favorites:   m.Favorites,
+				NewEpisodes: m.NewEpisodes,
+				ComputedAt:  now,
+			})
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("period = ?", period).Delete(&models.Ranking{}).Error; err != nil {
+			return err
+		}
+		if len(rankings) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rankings, 500).Error
+	})
+}
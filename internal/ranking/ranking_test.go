@@ -0,0 +1,75 @@
+package ranking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"challecara2025-back/internal/database"
+	"challecara2025-back/internal/migrations"
+	"challecara2025-back/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestComputeUsesCalendarDays(t *testing.T) {
+	db, err := database.OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	ctx := context.Background()
+	if _, err := migrations.New(db).Up(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	now := time.Date(2026, 3, 10, 6, 0, 0, 0, time.UTC)
+	today := models.StartOfDay(now)
+	book := models.Book{ID: uuid.New(), Title: "Book", Status: models.BookStatusPublished}
+	publishedAt := today.Add(time.Hour)
+	episode := models.Episode{ID: uuid.New(), BookID: book.ID, Title: "Episode", EpisodeNo: 1,
+		Status: models.EpisodeStatusPublished, PublishedAt: &publishedAt}
+	rows := []interface{}{
+		&book,
+		&episode,
+		// 24時間以内だが前日のお気に入り
+		&models.Reaction{UserID: uuid.New(), TargetType: models.ReactionTargetBook, TargetID: book.ID,
+			Kind: models.ReactionFavorite, CreatedAt: today.Add(-time.Hour)},
+		&models.EpisodeViewDaily{EpisodeID: episode.ID, BookID: book.ID, Day: today, Views: 3},
+		&models.EpisodeViewDaily{EpisodeID: episode.ID, BookID: book.ID, Day: today.AddDate(0, 0, -1), Views: 100},
+		&models.EpisodeViewDaily{EpisodeID: episode.ID, BookID: book.ID, Day: today.AddDate(0, 0, -6), Views: 7},
+		&models.EpisodeViewDaily{EpisodeID: episode.ID, BookID: book.ID, Day: today.AddDate(0, 0, -7), Views: 1000},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("insert %T: %v", row, err)
+		}
+	}
+
+	if err := Compute(ctx, db, now); err != nil {
+		t.Fatalf("Compute: %v", err)
+	}
+
+	tests := []struct {
+		period                        string
+		views, favorites, newEpisodes int64
+	}{
+		{period: models.RankingPeriodDaily, views: 3, favorites: 0, newEpisodes: 1},
+		{period: models.RankingPeriodWeekly, views: 110, favorites: 1, newEpisodes: 1},
+		{period: models.RankingPeriodAllTime, views: 1110, favorites: 1, newEpisodes: 1},
+	}
+	for _, tt := range tests {
+		var ranking models.Ranking
+		if err := db.Where("period = ? AND genre = ?", tt.period, "").First(&ranking).Error; err != nil {
+			t.Fatalf("%s: load ranking: %v", tt.period, err)
+		}
+		if ranking.Views != tt.views || ranking.Favorites != tt.favorites || ranking.NewEpisodes != tt.newEpisodes {
+			t.Errorf("%s: views = %d, favorites = %d, new episodes = %d, want %d, %d, %d", tt.period,
+				ranking.Views, ranking.Favorites, ranking.NewEpisodes, tt.views, tt.favorites, tt.newEpisodes)
+		}
+	}
+}
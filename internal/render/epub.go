@@ -0,0 +1,185 @@
+package render
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"challecara2025-back/internal/media"
+
+	"github.com/google/uuid"
+)
+
+// Chapter EPUBの1章（1エピソード）
+type Chapter struct {
+	Title   string
+	Content string
+}
+
+// Image EPUBに同梱する画像
+type Image struct {
+	ID          uuid.UUID
+	ContentType string
+	Data        []byte
+}
+
+// Book EPUBとして書き出すBook
+type Book struct {
+	ID          uuid.UUID
+	Title       string
+	Description string
+	Language    string
+	Modified    time.Time
+	Cover       *Image
+	Chapters    []Chapter
+	Images      []Image
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+// WriteEPUB BookをEPUB 3として書き出す。本文中の挿絵は同梱した画像に解決する
+func WriteEPUB(w io.Writer, book *Book) error {
+	language := book.Language
+	if language == "" {
+		language = "ja"
+	}
+
+	images := make(map[uuid.UUID]string, len(book.Images))
+	for _, img := range book.Images {
+		images[img.ID] = imagePath(img)
+	}
+	resolve := func(id uuid.UUID) (string, bool) {
+		path, ok := images[id]
+		return path, ok
+	}
+
+	zw := zip.NewWriter(w)
+
+	// mimetypeは無圧縮で先頭に置く必要がある
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		body string
+	}{
+		{"META-INF/container.xml", epubContainer},
+		{"OEBPS/content.opf", packageDocument(book, language)},
+		{"OEBPS/nav.xhtml", xhtmlDocument(language, book.Title, navBody(book))},
+	}
+	for i, chapter := range book.Chapters {
+		body := `<section epub:type="chapter">` + "\n<h1>" + escapeText(chapter.Title) + "</h1>\n" + HTML(chapter.Content, resolve) + "</section>"
+		files = append(files, struct {
+			name string
+			body string
+		}{"OEBPS/" + chapterPath(i), xhtmlDocument(language, chapter.Title, body)})
+	}
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, file.body); err != nil {
+			return err
+		}
+	}
+
+	binaries := book.Images
+	if book.Cover != nil {
+		binaries = append([]Image{*book.Cover}, binaries...)
+	}
+	for _, img := range binaries {
+		fw, err := zw.Create("OEBPS/" + imagePath(img))
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(img.Data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func packageDocument(book *Book, language string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="` + escapeText(language) + `">` + "\n")
+	b.WriteString(`  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">` + "\n")
+	b.WriteString(`    <dc:identifier id="book-id">urn:uuid:` + book.ID.String() + "</dc:identifier>\n")
+	b.WriteString("    <dc:title>" + escapeText(book.Title) + "</dc:title>\n")
+	b.WriteString("    <dc:language>" + escapeText(language) + "</dc:language>\n")
+	if book.Description != "" {
+		b.WriteString("    <dc:description>" + escapeText(book.Description) + "</dc:description>\n")
+	}
+	b.WriteString(`    <meta property="dcterms:modified">` + book.Modified.UTC().Format("2006-01-02T15:04:05Z") + "</meta>\n")
+	b.WriteString("  </metadata>\n")
+
+	b.WriteString("  <manifest>\n")
+	b.WriteString(`    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	if book.Cover != nil {
+		fmt.Fprintf(&b, `    <item id="cover-image" href="%s" media-type="%s" properties="cover-image"/>`+"\n", imagePath(*book.Cover), escapeText(book.Cover.ContentType))
+	}
+	for i := range book.Chapters {
+		fmt.Fprintf(&b, `    <item id="chapter-%d" href="%s" media-type="application/xhtml+xml"/>`+"\n", i+1, chapterPath(i))
+	}
+	for _, img := range book.Images {
+		fmt.Fprintf(&b, `    <item id="image-%s" href="%s" media-type="%s"/>`+"\n", img.ID, imagePath(img), escapeText(img.ContentType))
+	}
+	b.WriteString("  </manifest>\n")
+
+	b.WriteString("  <spine>\n")
+	for i := range book.Chapters {
+		fmt.Fprintf(&b, `    <itemref idref="chapter-%d"/>`+"\n", i+1)
+	}
+	b.WriteString("  </spine>\n")
+	b.WriteString("</package>\n")
+	return b.String()
+}
+
+func navBody(book *Book) string {
+	var b strings.Builder
+	b.WriteString(`<nav epub:type="toc" id="toc">` + "\n<h1>目次</h1>\n<ol>\n")
+	for i, chapter := range book.Chapters {
+		fmt.Fprintf(&b, `<li><a href="%s">%s</a></li>`+"\n", chapterPath(i), escapeText(chapter.Title))
+	}
+	b.WriteString("</ol>\n</nav>")
+	return b.String()
+}
+
+func xhtmlDocument(language, title, body string) string {
+	lang := escapeText(language)
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="` + lang + `" lang="` + lang + `">
+<head>
+<meta charset="UTF-8" />
+<title>` + escapeText(title) + `</title>
+</head>
+<body>
+` + body + `
+</body>
+</html>
+`
+}
+
+func chapterPath(i int) string {
+	return fmt.Sprintf("chapter-%d.xhtml", i+1)
+}
+
+func imagePath(img Image) string {
+	return "images/" + img.ID.String() + media.Extension(img.ContentType)
+}
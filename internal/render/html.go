@@ -0,0 +1,90 @@
+package render
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// imageToken 本文中の挿絵のマークアップ（[[image:<画像ID>]]）。
+// IDはImageTokenと同じ小文字のみ受け付ける（削除時の参照確認が大文字小文字を区別するLIKEでも漏れないように）
+var imageToken = regexp.MustCompile(`\[\[image:([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})\]\]`)
+
+// ImageResolver 画像IDから<img>のsrcを返す。見つからない場合はfalse
+type ImageResolver func(id uuid.UUID) (string, bool)
+
+// ImageToken 画像を本文に埋め込むためのマークアップ
+func ImageToken(id uuid.UUID) string {
+	return "[[image:" + id.String() + "]]"
+}
+
+// ImageIDs 本文が参照している画像IDを出現順に重複なく返す
+func ImageIDs(content string) []uuid.UUID {
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, match := range imageToken.FindAllStringSubmatch(content, -1) {
+		id, err := uuid.Parse(match[1])
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// HTML 本文を1行1段落のHTML（XHTMLとしても妥当）に変換し、挿絵のマークアップを画像に置き換える。
+// 解決できない画像は出力しない
+func HTML(content string, resolve ImageResolver) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		// 行全体が挿絵の場合は段落ではなく図として出力する
+		if loc := imageToken.FindStringSubmatchIndex(trimmed); loc != nil && loc[0] == 0 && loc[1] == len(trimmed) {
+			if img := imageTag(trimmed[loc[2]:loc[3]], resolve); img != "" {
+				b.WriteString(`<figure class="illustration">` + img + "</figure>\n")
+			}
+			continue
+		}
+
+		b.WriteString("<p>")
+		last := 0
+		for _, loc := range imageToken.FindAllStringSubmatchIndex(line, -1) {
+			b.WriteString(escapeText(line[last:loc[0]]))
+			b.WriteString(imageTag(line[loc[2]:loc[3]], resolve))
+			last = loc[1]
+		}
+		b.WriteString(escapeText(line[last:]))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
+
+func imageTag(rawID string, resolve ImageResolver) string {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return ""
+	}
+	src, ok := resolve(id)
+	if !ok {
+		return ""
+	}
+	return `<img class="illustration" src="` + html.EscapeString(src) + `" alt="挿絵" />`
+}
+
+// escapeText HTMLの特殊文字をエスケープし、XMLで使えない制御文字を取り除く
+func escapeText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if (r < 0x20 && r != '\t') || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+	return html.EscapeString(s)
+}
@@ -0,0 +1,28 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestImageIDsOnlyMatchesCanonicalTokens(t *testing.T) {
+	id := uuid.New()
+	content := ImageToken(id) + "\n" + strings.ToUpper(ImageToken(id)) + "\n" +
+		"[[image:" + strings.ToUpper(id.String()) + "]]\n" + ImageToken(id)
+
+	ids := ImageIDs(content)
+	if len(ids) != 1 || ids[0] != id {
+		t.Errorf("ImageIDs = %v, want [%s]", ids, id)
+	}
+
+	// 大文字のIDは挿絵として扱わず、本文のまま出力する
+	html := HTML("[[image:"+strings.ToUpper(id.String())+"]]", func(uuid.UUID) (string, bool) {
+		t.Error("resolver was called for an uppercase token")
+		return "", false
+	})
+	if !strings.Contains(html, strings.ToUpper(id.String())) {
+		t.Errorf("HTML = %q, want the token kept as text", html)
+	}
+}
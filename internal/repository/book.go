@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type gormBookRepository struct {
+	db *gorm.DB
+}
+
+// NewBookRepository GORMを使うBookRepository
+func NewBookRepository(db *gorm.DB) BookRepository {
+	return &gormBookRepository{db: db}
+}
+
+func (r *gormBookRepository) Create(ctx context.Context, book *models.Book) error {
+	return r.db.WithContext(ctx).Create(book).Error
+}
+
+func (r *gormBookRepository) List(ctx context.Context) ([]models.Book, error) {
+	var books []models.Book
+	err := r.db.WithContext(ctx).Preload("Episodes").Preload("Materials").Find(&books).Error
+	return books, err
+}
+
+func (r *gormBookRepository) Get(ctx context.Context, id uuid.UUID) (*models.Book, error) {
+	var book models.Book
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&book).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &book, nil
+}
+
+func (r *gormBookRepository) GetWithContents(ctx context.Context, id uuid.UUID) (*models.Book, error) {
+	var book models.Book
+	if err := r.db.WithContext(ctx).Preload("Episodes").Preload("Materials").Where("id = ?", id).First(&book).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &book, nil
+}
+
+// coverColumns 表紙のアップロードと同時に更新されても上書きしないよう、Updateで除く列
+var coverColumns = []string{"cover_image", "cover_thumb", "cover_key", "thumb_key"}
+
+func (r *gormBookRepository) Update(ctx context.Context, book *models.Book) error {
+	return r.db.WithContext(ctx).Omit(coverColumns...).Save(book).Error
+}
+
+func (r *gormBookRepository) UpdateStatus(ctx context.Context, book *models.Book) error {
+	return r.db.WithContext(ctx).Model(book).Select("status", "published_at", "completed_at").Updates(book).Error
+}
+
+func (r *gormBookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.Book{}).Error
+}
+
+func (r *gormBookRepository) Stats(ctx context.Context, bookID uuid.UUID) (*models.BookStats, error) {
+	stats := models.BookStats{BookID: bookID}
+	if err := r.db.WithContext(ctx).Where("book_id = ?", bookID).FirstOrInit(&stats).Error; err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (r *gormBookRepository) AuthorStats(ctx context.Context, authorID uuid.UUID) (*models.AuthorStats, error) {
+	stats := models.AuthorStats{AuthorID: authorID}
+	if err := r.db.WithContext(ctx).Where("author_id = ?", authorID).FirstOrInit(&stats).Error; err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (r *gormBookRepository) Images(ctx context.Context, bookID uuid.UUID, ids []uuid.UUID) ([]models.BookImage, error) {
+	var images []models.BookImage
+	if len(ids) == 0 {
+		return images, nil
+	}
+	err := r.db.WithContext(ctx).Where("book_id = ? AND id IN ?", bookID, ids).Find(&images).Error
+	return images, err
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type gormEpisodeRepository struct {
+	db *gorm.DB
+}
+
+// NewEpisodeRepository GORMを使うEpisodeRepository
+func NewEpisodeRepository(db *gorm.DB) EpisodeRepository {
+	return &gormEpisodeRepository{db: db}
+}
+
+func (r *gormEpisodeRepository) Create(ctx context.Context, episode *models.Episode) error {
+	return r.db.WithContext(ctx).Create(episode).Error
+}
+
+func (r *gormEpisodeRepository) ListByBook(ctx context.Context, bookID uuid.UUID, status string) ([]models.Episode, error) {
+	var episodes []models.Episode
+	query := r.db.WithContext(ctx).Where("book_id = ?", bookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("episode_no").Find(&episodes).Error
+	return episodes, err
+}
+
+func (r *gormEpisodeRepository) Get(ctx context.Context, id uuid.UUID) (*models.Episode, error) {
+	var episode models.Episode
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&episode).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &episode, nil
+}
+
+func (r *gormEpisodeRepository) FindByIDs(ctx context.Context, ids []uuid.UUID, bookID *uuid.UUID) ([]models.Episode, error) {
+	var episodes []models.Episode
+	query := r.db.WithContext(ctx).Where("id IN ?", ids)
+	if bookID != nil {
+		query = query.Where("book_id = ?", *bookID)
+	}
+	err := query.Find(&episodes).Error
+	return episodes, err
+}
+
+func (r *gormEpisodeRepository) Update(ctx context.Context, episode *models.Episode) error {
+	return r.db.WithContext(ctx).Save(episode).Error
+}
+
+func (r *gormEpisodeRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.Episode{}).Error
+}
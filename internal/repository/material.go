@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormMaterialRepository struct {
+	db *gorm.DB
+}
+
+// NewMaterialRepository GORMを使うMaterialRepository
+func NewMaterialRepository(db *gorm.DB) MaterialRepository {
+	return &gormMaterialRepository{db: db}
+}
+
+func (r *gormMaterialRepository) Create(ctx context.Context, material *models.Material) error {
+	return r.db.WithContext(ctx).Create(material).Error
+}
+
+func (r *gormMaterialRepository) Get(ctx context.Context, id uuid.UUID) (*models.Material, error) {
+	var material models.Material
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&material).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &material, nil
+}
+
+// visible Bookから参照できる参考資料に絞り込むクエリを返す
+func (r *gormMaterialRepository) visible(ctx context.Context, book *models.Book) *gorm.DB {
+	db := r.db.WithContext(ctx)
+	query := db.Where("book_id = ?", book.ID).
+		Or("id IN (?)", db.Model(&models.BookMaterial{}).Select("material_id").Where("book_id = ?", book.ID))
+	if book.SeriesID != nil {
+		query = query.Or("series_id = ?", *book.SeriesID)
+	}
+	if book.AuthorID != uuid.Nil {
+		query = query.Or("author_id = ? AND book_id IS NULL AND series_id IS NULL", book.AuthorID)
+	}
+	return db.Where(query)
+}
+
+func (r *gormMaterialRepository) ListVisible(ctx context.Context, book *models.Book) ([]models.Material, error) {
+	var materials []models.Material
+	err := r.visible(ctx, book).Order("created_at DESC").Find(&materials).Error
+	return materials, err
+}
+
+func (r *gormMaterialRepository) FindByIDs(ctx context.Context, ids []uuid.UUID, book *models.Book) ([]models.Material, error) {
+	var materials []models.Material
+	query := r.db.WithContext(ctx)
+	if book != nil {
+		query = r.visible(ctx, book)
+	}
+	err := query.Where("id IN ?", ids).Find(&materials).Error
+	return materials, err
+}
+
+func (r *gormMaterialRepository) ListLibrary(ctx context.Context, authorID uuid.UUID) ([]models.Material, error) {
+	var materials []models.Material
+	err := r.db.WithContext(ctx).
+		Where("author_id = ? AND book_id IS NULL AND series_id IS NULL", authorID).
+		Order("created_at DESC").
+		Find(&materials).Error
+	return materials, err
+}
+
+func (r *gormMaterialRepository) Update(ctx context.Context, material *models.Material) error {
+	return r.db.WithContext(ctx).Save(material).Error
+}
+
+func (r *gormMaterialRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("material_id = ?", id).Delete(&models.BookMaterial{}).Error; err != nil {
+			return err
+		}
+		// 添付ファイルも削除し、作者の使用量から外す
+		if err := tx.Where("material_id = ?", id).Delete(&models.Attachment{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Material{}).Error
+	})
+}
+
+func (r *gormMaterialRepository) Attach(ctx context.Context, bookID, materialID uuid.UUID) error {
+	link := models.BookMaterial{BookID: bookID, MaterialID: materialID}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&link).Error
+}
+
+func (r *gormMaterialRepository) Detach(ctx context.Context, bookID, materialID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("book_id = ? AND material_id = ?", bookID, materialID).Delete(&models.BookMaterial{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormMaterialRepository) Owner(ctx context.Context, material *models.Material) (uuid.UUID, error) {
+	// 削除済みのシリーズ・Bookの資料も作者は変わらない
+	db := r.db.WithContext(ctx).Unscoped()
+	var authorIDs []uuid.UUID
+	switch {
+	case material.AuthorID != nil:
+		return *material.AuthorID, nil
+	case material.SeriesID != nil:
+		db = db.Model(&models.Series{}).Where("id = ?", *material.SeriesID)
+	case material.BookID != nil:
+		db = db.Model(&models.Book{}).Where("id = ?", *material.BookID)
+	default:
+		return uuid.Nil, nil
+	}
+	if err := db.Limit(1).Pluck("author_id", &authorIDs).Error; err != nil {
+		return uuid.Nil, err
+	}
+	if len(authorIDs) == 0 {
+		return uuid.Nil, nil
+	}
+	return authorIDs[0], nil
+}
@@ -0,0 +1,383 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MemoryBookRepository テスト用のメモリ上のBookRepository
+// （エピソードや参考資料の関連は解決せず、保存された値をそのまま返す）
+type MemoryBookRepository struct {
+	mu          sync.Mutex
+	books       map[uuid.UUID]models.Book
+	stats       map[uuid.UUID]models.BookStats
+	authorStats map[uuid.UUID]models.AuthorStats
+	images      map[uuid.UUID]models.BookImage
+}
+
+func NewMemoryBookRepository() *MemoryBookRepository {
+	return &MemoryBookRepository{
+		books:       make(map[uuid.UUID]models.Book),
+		stats:       make(map[uuid.UUID]models.BookStats),
+		authorStats: make(map[uuid.UUID]models.AuthorStats),
+		images:      make(map[uuid.UUID]models.BookImage),
+	}
+}
+
+// PutStats Bookの集計値を設定する
+func (r *MemoryBookRepository) PutStats(stats models.BookStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats[stats.BookID] = stats
+}
+
+// PutAuthorStats 作者の集計値を設定する
+func (r *MemoryBookRepository) PutAuthorStats(stats models.AuthorStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authorStats[stats.AuthorID] = stats
+}
+
+// PutImage 挿絵を登録する
+func (r *MemoryBookRepository) PutImage(image models.BookImage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.images[image.ID] = image
+}
+
+func (r *MemoryBookRepository) Create(_ context.Context, book *models.Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	book.CreatedAt, book.UpdatedAt = now, now
+	r.books[book.ID] = *book
+	return nil
+}
+
+func (r *MemoryBookRepository) List(_ context.Context) ([]models.Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	books := make([]models.Book, 0, len(r.books))
+	for _, book := range r.books {
+		books = append(books, book)
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].ID.String() < books[j].ID.String() })
+	return books, nil
+}
+
+func (r *MemoryBookRepository) Get(_ context.Context, id uuid.UUID) (*models.Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	book, ok := r.books[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &book, nil
+}
+
+func (r *MemoryBookRepository) GetWithContents(ctx context.Context, id uuid.UUID) (*models.Book, error) {
+	return r.Get(ctx, id)
+}
+
+func (r *MemoryBookRepository) Update(_ context.Context, book *models.Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	book.UpdatedAt = time.Now()
+	updated := *book
+	if stored, ok := r.books[book.ID]; ok {
+		updated.CoverImage, updated.CoverThumb = stored.CoverImage, stored.CoverThumb
+		updated.CoverKey, updated.ThumbKey = stored.CoverKey, stored.ThumbKey
+	}
+	r.books[book.ID] = updated
+	return nil
+}
+
+func (r *MemoryBookRepository) UpdateStatus(_ context.Context, book *models.Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.books[book.ID]
+	if !ok {
+		return nil
+	}
+	stored.Status = book.Status
+	stored.PublishedAt = book.PublishedAt
+	stored.CompletedAt = book.CompletedAt
+	stored.UpdatedAt = time.Now()
+	r.books[book.ID] = stored
+	return nil
+}
+
+func (r *MemoryBookRepository) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.books, id)
+	return nil
+}
+
+func (r *MemoryBookRepository) Stats(_ context.Context, bookID uuid.UUID) (*models.BookStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats, ok := r.stats[bookID]
+	if !ok {
+		stats = models.BookStats{BookID: bookID}
+	}
+	return &stats, nil
+}
+
+func (r *MemoryBookRepository) AuthorStats(_ context.Context, authorID uuid.UUID) (*models.AuthorStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats, ok := r.authorStats[authorID]
+	if !ok {
+		stats = models.AuthorStats{AuthorID: authorID}
+	}
+	return &stats, nil
+}
+
+func (r *MemoryBookRepository) Images(_ context.Context, bookID uuid.UUID, ids []uuid.UUID) ([]models.BookImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var images []models.BookImage
+	for _, id := range ids {
+		if image, ok := r.images[id]; ok && image.BookID == bookID {
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// MemoryEpisodeRepository テスト用のメモリ上のEpisodeRepository
+type MemoryEpisodeRepository struct {
+	mu       sync.Mutex
+	episodes map[uuid.UUID]models.Episode
+}
+
+func NewMemoryEpisodeRepository() *MemoryEpisodeRepository {
+	return &MemoryEpisodeRepository{episodes: make(map[uuid.UUID]models.Episode)}
+}
+
+func (r *MemoryEpisodeRepository) Create(_ context.Context, episode *models.Episode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	episode.CreatedAt, episode.UpdatedAt = now, now
+	r.episodes[episode.ID] = *episode
+	return nil
+}
+
+func (r *MemoryEpisodeRepository) ListByBook(_ context.Context, bookID uuid.UUID, status string) ([]models.Episode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	episodes := []models.Episode{}
+	for _, episode := range r.episodes {
+		if episode.BookID == bookID && (status == "" || episode.Status == status) {
+			episodes = append(episodes, episode)
+		}
+	}
+	sort.Slice(episodes, func(i, j int) bool { return episodes[i].EpisodeNo < episodes[j].EpisodeNo })
+	return episodes, nil
+}
+
+func (r *MemoryEpisodeRepository) Get(_ context.Context, id uuid.UUID) (*models.Episode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	episode, ok := r.episodes[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &episode, nil
+}
+
+func (r *MemoryEpisodeRepository) FindByIDs(_ context.Context, ids []uuid.UUID, bookID *uuid.UUID) ([]models.Episode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	episodes := []models.Episode{}
+	for _, id := range ids {
+		episode, ok := r.episodes[id]
+		if ok && (bookID == nil || episode.BookID == *bookID) {
+			episodes = append(episodes, episode)
+		}
+	}
+	return episodes, nil
+}
+
+func (r *MemoryEpisodeRepository) Update(_ context.Context, episode *models.Episode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	episode.UpdatedAt = time.Now()
+	r.episodes[episode.ID] = *episode
+	return nil
+}
+
+func (r *MemoryEpisodeRepository) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.episodes, id)
+	return nil
+}
+
+// MemoryMaterialRepository テスト用のメモリ上のMaterialRepository
+type MemoryMaterialRepository struct {
+	mu        sync.Mutex
+	materials map[uuid.UUID]models.Material
+	links     map[models.BookMaterial]bool
+	// authors シリーズ・BookのIDから作者のID（Ownerで使う）
+	authors map[uuid.UUID]uuid.UUID
+}
+
+func NewMemoryMaterialRepository() *MemoryMaterialRepository {
+	return &MemoryMaterialRepository{
+		materials: make(map[uuid.UUID]models.Material),
+		links:     make(map[models.BookMaterial]bool),
+		authors:   make(map[uuid.UUID]uuid.UUID),
+	}
+}
+
+// PutAuthor シリーズまたはBookの作者を登録する
+func (r *MemoryMaterialRepository) PutAuthor(id, authorID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authors[id] = authorID
+}
+
+func (r *MemoryMaterialRepository) Create(_ context.Context, material *models.Material) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	material.CreatedAt, material.UpdatedAt = now, now
+	r.materials[material.ID] = *material
+	return nil
+}
+
+func (r *MemoryMaterialRepository) Get(_ context.Context, id uuid.UUID) (*models.Material, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	material, ok := r.materials[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &material, nil
+}
+
+// isVisible r.muを保持した状態で呼び出す
+func (r *MemoryMaterialRepository) isVisible(material *models.Material, book *models.Book) bool {
+	switch {
+	case material.BookID != nil && *material.BookID == book.ID:
+		return true
+	case r.links[models.BookMaterial{BookID: book.ID, MaterialID: material.ID}]:
+		return true
+	case material.SeriesID != nil && book.SeriesID != nil && *material.SeriesID == *book.SeriesID:
+		return true
+	case material.AuthorID != nil && book.AuthorID != uuid.Nil && *material.AuthorID == book.AuthorID &&
+		material.BookID == nil && material.SeriesID == nil:
+		return true
+	}
+	return false
+}
+
+func (r *MemoryMaterialRepository) ListVisible(_ context.Context, book *models.Book) ([]models.Material, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	materials := []models.Material{}
+	for _, material := range r.materials {
+		if r.isVisible(&material, book) {
+			materials = append(materials, material)
+		}
+	}
+	sortNewestFirst(materials)
+	return materials, nil
+}
+
+func (r *MemoryMaterialRepository) FindByIDs(_ context.Context, ids []uuid.UUID, book *models.Book) ([]models.Material, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	materials := []models.Material{}
+	for _, id := range ids {
+		material, ok := r.materials[id]
+		if ok && (book == nil || r.isVisible(&material, book)) {
+			materials = append(materials, material)
+		}
+	}
+	return materials, nil
+}
+
+func (r *MemoryMaterialRepository) ListLibrary(_ context.Context, authorID uuid.UUID) ([]models.Material, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	materials := []models.Material{}
+	for _, material := range r.materials {
+		if material.AuthorID != nil && *material.AuthorID == authorID && material.BookID == nil && material.SeriesID == nil {
+			materials = append(materials, material)
+		}
+	}
+	sortNewestFirst(materials)
+	return materials, nil
+}
+
+func (r *MemoryMaterialRepository) Update(_ context.Context, material *models.Material) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	material.UpdatedAt = time.Now()
+	r.materials[material.ID] = *material
+	return nil
+}
+
+func (r *MemoryMaterialRepository) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for link := range r.links {
+		if link.MaterialID == id {
+			delete(r.links, link)
+		}
+	}
+	delete(r.materials, id)
+	return nil
+}
+
+func (r *MemoryMaterialRepository) Attach(_ context.Context, bookID, materialID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.links[models.BookMaterial{BookID: bookID, MaterialID: materialID}] = true
+	return nil
+}
+
+func (r *MemoryMaterialRepository) Detach(_ context.Context, bookID, materialID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	link := models.BookMaterial{BookID: bookID, MaterialID: materialID}
+	if !r.links[link] {
+		return ErrNotFound
+	}
+	delete(r.links, link)
+	return nil
+}
+
+func (r *MemoryMaterialRepository) Owner(_ context.Context, material *models.Material) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case material.AuthorID != nil:
+		return *material.AuthorID, nil
+	case material.SeriesID != nil:
+		return r.authors[*material.SeriesID], nil
+	case material.BookID != nil:
+		return r.authors[*material.BookID], nil
+	}
+	return uuid.Nil, nil
+}
+
+func sortNewestFirst(materials []models.Material) {
+	sort.Slice(materials, func(i, j int) bool { return materials[i].CreatedAt.After(materials[j].CreatedAt) })
+}
+
+var (
+	_ BookRepository     = (*MemoryBookRepository)(nil)
+	_ EpisodeRepository  = (*MemoryEpisodeRepository)(nil)
+	_ MaterialRepository = (*MemoryMaterialRepository)(nil)
+)
@@ -0,0 +1,78 @@
+// Package repository ハンドラーからデータベースへのアクセスを切り離すためのリポジトリ
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"challecara2025-back/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound 対象のレコードが存在しない
+var ErrNotFound = errors.New("record not found")
+
+// BookRepository Bookの永続化
+type BookRepository interface {
+	Create(ctx context.Context, book *models.Book) error
+	// List エピソードと参考資料を含めてすべてのBookを返す
+	List(ctx context.Context) ([]models.Book, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.Book, error)
+	// GetWithContents エピソードと参考資料を含めてBookを返す
+	GetWithContents(ctx context.Context, id uuid.UUID) (*models.Book, error)
+	// Update 表紙画像の列以外を更新する（表紙は表紙のAPIでのみ変更する）
+	Update(ctx context.Context, book *models.Book) error
+	// UpdateStatus 公開ステータスと公開・完結日時のみを更新する
+	UpdateStatus(ctx context.Context, book *models.Book) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Stats Bookの集計値を返す（まだ行が無ければ0件として返す）
+	Stats(ctx context.Context, bookID uuid.UUID) (*models.BookStats, error)
+	// AuthorStats 作者の集計値を返す（まだ行が無ければ0件として返す）
+	AuthorStats(ctx context.Context, authorID uuid.UUID) (*models.AuthorStats, error)
+	// Images Bookの挿絵のうちidsに含まれるものを返す
+	Images(ctx context.Context, bookID uuid.UUID, ids []uuid.UUID) ([]models.BookImage, error)
+}
+
+// EpisodeRepository Episodeの永続化
+type EpisodeRepository interface {
+	Create(ctx context.Context, episode *models.Episode) error
+	// ListByBook Bookのエピソードを話数順に返す（statusが空でなければ絞り込む）
+	ListByBook(ctx context.Context, bookID uuid.UUID, status string) ([]models.Episode, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.Episode, error)
+	// FindByIDs idsに含まれるエピソードを返す（bookIDがnilでなければ絞り込む）
+	FindByIDs(ctx context.Context, ids []uuid.UUID, bookID *uuid.UUID) ([]models.Episode, error)
+	Update(ctx context.Context, episode *models.Episode) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// MaterialRepository 参考資料の永続化
+type MaterialRepository interface {
+	Create(ctx context.Context, material *models.Material) error
+	Get(ctx context.Context, id uuid.UUID) (*models.Material, error)
+	// ListVisible Bookから参照できる参考資料を新しい順に返す
+	// （Book固有 + 共有されたもの + シリーズ共有 + 作者ライブラリ）
+	ListVisible(ctx context.Context, book *models.Book) ([]models.Material, error)
+	// FindByIDs idsに含まれる参考資料を返す（bookがnilでなければ参照できるものに絞り込む）
+	FindByIDs(ctx context.Context, ids []uuid.UUID, book *models.Book) ([]models.Material, error)
+	// ListLibrary 作者ライブラリの参考資料を新しい順に返す
+	ListLibrary(ctx context.Context, authorID uuid.UUID) ([]models.Material, error)
+	Update(ctx context.Context, material *models.Material) error
+	// Delete 参考資料と、Bookとの紐付け・添付ファイルを削除する
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Attach 参考資料をBookから参照できるようにする（既に紐付いていれば何もしない）
+	Attach(ctx context.Context, bookID, materialID uuid.UUID) error
+	// Detach Bookとの紐付けを外す。紐付いていなければErrNotFound
+	Detach(ctx context.Context, bookID, materialID uuid.UUID) error
+	// Owner 参考資料の作者（作者ライブラリの作者、シリーズまたはBookの作者）を返す。分からなければuuid.Nil
+	Owner(ctx context.Context, material *models.Material) (uuid.UUID, error)
+}
+
+// translate GORMのエラーをリポジトリのエラーに変換する
+func translate(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,384 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"testing"
+
+	"challecara2025-back/internal/database"
+	"challecara2025-back/internal/migrations"
+	"challecara2025-back/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// repositories テスト対象のリポジトリの組
+type repositories struct {
+	books     BookRepository
+	episodes  EpisodeRepository
+	materials MaterialRepository
+	// newSeries シリーズを作成してIDを返す（シリーズはリポジトリを持たない）
+	newSeries func(t *testing.T, authorID uuid.UUID) uuid.UUID
+	// registerAuthor シリーズ・Bookの作者を参考資料のリポジトリに知らせる（メモリ上の実装のみ）
+	registerAuthor func(id, authorID uuid.UUID)
+}
+
+// implementations GORM（メモリ上のSQLiteにマイグレーションを適用）とメモリ上の実装を返す。
+// 同じテストを両方に対して実行し、テスト用のメモリ上の実装がGORMの実装と同じ振る舞いをすることも確認する
+func implementations() map[string]func(t *testing.T) repositories {
+	return map[string]func(t *testing.T) repositories{
+		"gorm": func(t *testing.T) repositories {
+			db, err := database.OpenSQLite(":memory:")
+			if err != nil {
+				t.Fatalf("open sqlite: %v", err)
+			}
+			t.Cleanup(func() {
+				if sqlDB, err := db.DB(); err == nil {
+					sqlDB.Close()
+				}
+			})
+			if _, err := migrations.New(db).Up(context.Background()); err != nil {
+				t.Fatalf("migrate: %v", err)
+			}
+			return repositories{
+				books:     NewBookRepository(db),
+				episodes:  NewEpisodeRepository(db),
+				materials: NewMaterialRepository(db),
+				newSeries: func(t *testing.T, authorID uuid.UUID) uuid.UUID {
+					series := models.Series{ID: uuid.New(), Title: "Series", AuthorID: authorID}
+					if err := db.Create(&series).Error; err != nil {
+						t.Fatalf("create series: %v", err)
+					}
+					return series.ID
+				},
+				registerAuthor: func(uuid.UUID, uuid.UUID) {},
+			}
+		},
+		"memory": func(t *testing.T) repositories {
+			materials := NewMemoryMaterialRepository()
+			return repositories{
+				books:     NewMemoryBookRepository(),
+				episodes:  NewMemoryEpisodeRepository(),
+				materials: materials,
+				newSeries: func(_ *testing.T, authorID uuid.UUID) uuid.UUID {
+					id := uuid.New()
+					materials.PutAuthor(id, authorID)
+					return id
+				},
+				registerAuthor: materials.PutAuthor,
+			}
+		},
+	}
+}
+
+// forEach 各実装に対してtestを実行する
+func forEach(t *testing.T, test func(t *testing.T, repos repositories)) {
+	for name, open := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			test(t, open(t))
+		})
+	}
+}
+
+func createBook(t *testing.T, repos repositories, book models.Book) models.Book {
+	t.Helper()
+	book.ID = uuid.New()
+	if book.Title == "" {
+		book.Title = "Book"
+	}
+	if book.Status == "" {
+		book.Status = models.BookStatusDraft
+	}
+	if err := repos.books.Create(context.Background(), &book); err != nil {
+		t.Fatalf("create book: %v", err)
+	}
+	repos.registerAuthor(book.ID, book.AuthorID)
+	return book
+}
+
+func createEpisode(t *testing.T, repos repositories, episode models.Episode) models.Episode {
+	t.Helper()
+	episode.ID = uuid.New()
+	if episode.Status == "" {
+		episode.Status = models.EpisodeStatusDraft
+	}
+	if err := repos.episodes.Create(context.Background(), &episode); err != nil {
+		t.Fatalf("create episode: %v", err)
+	}
+	return episode
+}
+
+func createMaterial(t *testing.T, repos repositories, material models.Material) models.Material {
+	t.Helper()
+	material.ID = uuid.New()
+	material.Title, material.Content = "Material", "..."
+	if err := repos.materials.Create(context.Background(), &material); err != nil {
+		t.Fatalf("create material: %v", err)
+	}
+	return material
+}
+
+func ids[T any](items []T, id func(T) uuid.UUID) []string {
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i] = id(item).String()
+	}
+	sort.Strings(out)
+	return out
+}
+
+func materialID(m models.Material) uuid.UUID { return m.ID }
+
+func TestBookRepository(t *testing.T) {
+	forEach(t, func(t *testing.T, repos repositories) {
+		ctx := context.Background()
+		book := createBook(t, repos, models.Book{Title: "Book", Genre: "fantasy"})
+
+		got, err := repos.books.Get(ctx, book.ID)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.Title != "Book" || got.Genre != "fantasy" {
+			t.Errorf("Get = %+v, want the created book", got)
+		}
+
+		got.Title = "Renamed"
+		if err := repos.books.Update(ctx, got); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got, _ = repos.books.Get(ctx, book.ID)
+		if got.Title != "Renamed" {
+			t.Errorf("title after Update = %q, want %q", got.Title, "Renamed")
+		}
+
+		// UpdateStatus はステータス以外を更新しない
+		got.Title = "Ignored"
+		if err := got.TransitionStatus(models.BookStatusPublished, got.UpdatedAt); err != nil {
+			t.Fatalf("TransitionStatus: %v", err)
+		}
+		if err := repos.books.UpdateStatus(ctx, got); err != nil {
+			t.Fatalf("UpdateStatus: %v", err)
+		}
+		got, _ = repos.books.Get(ctx, book.ID)
+		if got.Status != models.BookStatusPublished || got.PublishedAt == nil || got.Title != "Renamed" {
+			t.Errorf("after UpdateStatus = %+v, want published with title unchanged", got)
+		}
+
+		if err := repos.books.Delete(ctx, book.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repos.books.Get(ctx, book.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get after Delete: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestBookRepositoryUpdateKeepsCover(t *testing.T) {
+	forEach(t, func(t *testing.T, repos repositories) {
+		ctx := context.Background()
+		book := createBook(t, repos, models.Book{
+			CoverImage: "/uploads/new.png", CoverThumb: "/uploads/new_thumb.png",
+			CoverKey: "new.png", ThumbKey: "new_thumb.png",
+		})
+
+		// 表紙のアップロード前に読み込んだBookで更新しても、表紙は戻らない
+		stale := book
+		stale.Title = "Renamed"
+		stale.CoverImage, stale.CoverThumb, stale.CoverKey, stale.ThumbKey = "", "", "old.png", "old_thumb.png"
+		if err := repos.books.Update(ctx, &stale); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, _ := repos.books.Get(ctx, book.ID)
+		if got.Title != "Renamed" {
+			t.Errorf("title = %q, want %q", got.Title, "Renamed")
+		}
+		if got.CoverImage != book.CoverImage || got.CoverThumb != book.CoverThumb ||
+			got.CoverKey != book.CoverKey || got.ThumbKey != book.ThumbKey {
+			t.Errorf("cover = %q %q %q %q, want unchanged", got.CoverImage, got.CoverThumb, got.CoverKey, got.ThumbKey)
+		}
+	})
+}
+
+func TestBookRepositoryStatsDefaultToZero(t *testing.T) {
+	forEach(t, func(t *testing.T, repos repositories) {
+		ctx := context.Background()
+		bookID, authorID := uuid.New(), uuid.New()
+
+		stats, err := repos.books.Stats(ctx, bookID)
+		if err != nil {
+			t.Fatalf("Stats: %v", err)
+		}
+		if stats.BookID != bookID || stats.LikeCount != 0 {
+			t.Errorf("Stats = %+v, want zero counts for %s", stats, bookID)
+		}
+
+		authorStats, err := repos.books.AuthorStats(ctx, authorID)
+		if err != nil {
+			t.Fatalf("AuthorStats: %v", err)
+		}
+		if authorStats.AuthorID != authorID || authorStats.FollowerCount != 0 {
+			t.Errorf("AuthorStats = %+v, want zero counts for %s", authorStats, authorID)
+		}
+	})
+}
+
+func TestEpisodeRepository(t *testing.T) {
+	forEach(t, func(t *testing.T, repos repositories) {
+		ctx := context.Background()
+		book := createBook(t, repos, models.Book{})
+		other := createBook(t, repos, models.Book{})
+
+		second := createEpisode(t, repos, models.Episode{BookID: book.ID, Title: "2", EpisodeNo: 2, Status: models.EpisodeStatusPublished})
+		first := createEpisode(t, repos, models.Episode{BookID: book.ID, Title: "1", EpisodeNo: 1, Status: models.EpisodeStatusPublished})
+		createEpisode(t, repos, models.Episode{BookID: book.ID, Title: "3", EpisodeNo: 3})
+		foreign := createEpisode(t, repos, models.Episode{BookID: other.ID, Title: "1", EpisodeNo: 1})
+
+		all, err := repos.episodes.ListByBook(ctx, book.ID, "")
+		if err != nil {
+			t.Fatalf("ListByBook: %v", err)
+		}
+		if len(all) != 3 || all[0].EpisodeNo != 1 || all[2].EpisodeNo != 3 {
+			t.Errorf("ListByBook = %+v, want episodes 1-3 in order", all)
+		}
+
+		published, err := repos.episodes.ListByBook(ctx, book.ID, models.EpisodeStatusPublished)
+		if err != nil {
+			t.Fatalf("ListByBook(published): %v", err)
+		}
+		if len(published) != 2 || published[0].ID != first.ID || published[1].ID != second.ID {
+			t.Errorf("ListByBook(published) = %+v, want episodes 1 and 2", published)
+		}
+
+		found, err := repos.episodes.FindByIDs(ctx, []uuid.UUID{first.ID, foreign.ID}, &book.ID)
+		if err != nil {
+			t.Fatalf("FindByIDs: %v", err)
+		}
+		if len(found) != 1 || found[0].ID != first.ID {
+			t.Errorf("FindByIDs(book) = %+v, want only %s", found, first.ID)
+		}
+		found, _ = repos.episodes.FindByIDs(ctx, []uuid.UUID{first.ID, foreign.ID}, nil)
+		if len(found) != 2 {
+			t.Errorf("FindByIDs(nil) returned %d episodes, want 2", len(found))
+		}
+
+		if err := repos.episodes.Delete(ctx, first.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repos.episodes.Get(ctx, first.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get after Delete: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestMaterialRepositoryVisibility(t *testing.T) {
+	forEach(t, func(t *testing.T, repos repositories) {
+		ctx := context.Background()
+		authorID, otherAuthor := uuid.New(), uuid.New()
+		seriesID := repos.newSeries(t, authorID)
+		book := createBook(t, repos, models.Book{AuthorID: authorID, SeriesID: &seriesID})
+		other := createBook(t, repos, models.Book{AuthorID: authorID})
+
+		own := createMaterial(t, repos, models.Material{BookID: &book.ID})
+		series := createMaterial(t, repos, models.Material{SeriesID: &seriesID})
+		library := createMaterial(t, repos, models.Material{AuthorID: &authorID})
+		shared := createMaterial(t, repos, models.Material{BookID: &other.ID})
+		hidden := createMaterial(t, repos, models.Material{BookID: &other.ID})
+		createMaterial(t, repos, models.Material{AuthorID: &otherAuthor})
+
+		if err := repos.materials.Attach(ctx, book.ID, shared.ID); err != nil {
+			t.Fatalf("Attach: %v", err)
+		}
+		// 二度目の紐付けは何もしない
+		if err := repos.materials.Attach(ctx, book.ID, shared.ID); err != nil {
+			t.Fatalf("Attach again: %v", err)
+		}
+
+		visible, err := repos.materials.ListVisible(ctx, &book)
+		if err != nil {
+			t.Fatalf("ListVisible: %v", err)
+		}
+		want := ids([]models.Material{own, series, library, shared}, materialID)
+		if got := ids(visible, materialID); !slices.Equal(got, want) {
+			t.Errorf("ListVisible = %v, want %v", got, want)
+		}
+
+		found, err := repos.materials.FindByIDs(ctx, []uuid.UUID{own.ID, hidden.ID}, &book)
+		if err != nil {
+			t.Fatalf("FindByIDs: %v", err)
+		}
+		if len(found) != 1 || found[0].ID != own.ID {
+			t.Errorf("FindByIDs(book) = %v, want only %s", ids(found, materialID), own.ID)
+		}
+
+		libraryMaterials, err := repos.materials.ListLibrary(ctx, authorID)
+		if err != nil {
+			t.Fatalf("ListLibrary: %v", err)
+		}
+		if len(libraryMaterials) != 1 || libraryMaterials[0].ID != library.ID {
+			t.Errorf("ListLibrary = %v, want only %s", ids(libraryMaterials, materialID), library.ID)
+		}
+
+		if err := repos.materials.Detach(ctx, book.ID, shared.ID); err != nil {
+			t.Fatalf("Detach: %v", err)
+		}
+		if err := repos.materials.Detach(ctx, book.ID, shared.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Detach again: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestMaterialRepositoryOwner(t *testing.T) {
+	forEach(t, func(t *testing.T, repos repositories) {
+		ctx := context.Background()
+		libraryAuthor, seriesAuthor, bookAuthor := uuid.New(), uuid.New(), uuid.New()
+		seriesID := repos.newSeries(t, seriesAuthor)
+		book := createBook(t, repos, models.Book{AuthorID: bookAuthor})
+		missingBook := uuid.New()
+
+		tests := []struct {
+			name     string
+			material models.Material
+			want     uuid.UUID
+		}{
+			{name: "library", material: models.Material{AuthorID: &libraryAuthor}, want: libraryAuthor},
+			{name: "series", material: models.Material{SeriesID: &seriesID}, want: seriesAuthor},
+			{name: "book", material: models.Material{BookID: &book.ID}, want: bookAuthor},
+			{name: "missing book", material: models.Material{BookID: &missingBook}, want: uuid.Nil},
+			{name: "none", material: models.Material{}, want: uuid.Nil},
+		}
+		for _, tt := range tests {
+			owner, err := repos.materials.Owner(ctx, &tt.material)
+			if err != nil {
+				t.Fatalf("%s: Owner: %v", tt.name, err)
+			}
+			if owner != tt.want {
+				t.Errorf("%s: Owner = %s, want %s", tt.name, owner, tt.want)
+			}
+		}
+	})
+}
+
+func TestMaterialRepositoryDeleteRemovesLinks(t *testing.T) {
+	forEach(t, func(t *testing.T, repos repositories) {
+		ctx := context.Background()
+		book := createBook(t, repos, models.Book{})
+		other := createBook(t, repos, models.Book{})
+		material := createMaterial(t, repos, models.Material{BookID: &other.ID})
+		if err := repos.materials.Attach(ctx, book.ID, material.ID); err != nil {
+			t.Fatalf("Attach: %v", err)
+		}
+
+		if err := repos.materials.Delete(ctx, material.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repos.materials.Get(ctx, material.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get after Delete: err = %v, want ErrNotFound", err)
+		}
+		if err := repos.materials.Detach(ctx, book.ID, material.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Detach after Delete: err = %v, want ErrNotFound", err)
+		}
+	})
+}
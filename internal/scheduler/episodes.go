@@ -0,0 +1,32 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"challecara2025-back/internal/metrics"
+	"challecara2025-back/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// PublishDueEpisodes 予約公開日時を過ぎたエピソードを公開済みにするジョブ
+func PublishDueEpisodes(db *gorm.DB) Job {
+	return func(ctx context.Context, now time.Time) error {
+		result := db.WithContext(ctx).Model(&models.Episode{}).
+			Where("status = ? AND publish_at <= ?", models.EpisodeStatusScheduled, now).
+			Updates(map[string]interface{}{
+				"status":       models.EpisodeStatusPublished,
+				"published_at": gorm.Expr("publish_at"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			metrics.EpisodesPublished.Add(float64(result.RowsAffected))
+			slog.InfoContext(ctx, "Published scheduled episodes", "count", result.RowsAffected)
+		}
+		return nil
+	}
+}
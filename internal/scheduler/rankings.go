@@ -0,0 +1,17 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"challecara2025-back/internal/ranking"
+
+	"gorm.io/gorm"
+)
+
+// ComputeRankings ランキングを集計し直すジョブ
+func ComputeRankings(db *gorm.DB) Job {
+	return func(ctx context.Context, now time.Time) error {
+		return ranking.Compute(ctx, db, now)
+	}
+}
@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job 定期実行する処理
+type Job func(ctx context.Context, now time.Time) error
+
+type entry struct {
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+// Scheduler 登録されたジョブをAPIプロセス内で一定間隔ごとに実行する
+type Scheduler struct {
+	entries []entry
+	wg      sync.WaitGroup
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every ジョブを登録する（Start前に呼び出す）
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	s.entries = append(s.entries, entry{name: name, interval: interval, job: job})
+}
+
+// Start 登録済みのジョブをそれぞれゴルーチンで開始する。ctxがキャンセルされると停止する
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go func(e entry) {
+			defer s.wg.Done()
+			s.loop(ctx, e)
+		}(e)
+	}
+}
+
+// Wait 実行中のジョブがすべて停止するまで待つ
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, e entry) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	// 起動直後に一度実行してから一定間隔で繰り返す
+	s.run(ctx, e, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.run(ctx, e, now)
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, e entry, now time.Time) {
+	if err := e.job(ctx, now); err != nil {
+		slog.ErrorContext(ctx, "Scheduled job failed", "job", e.name, "error", err)
+	}
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoragePublicHandler(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), LocalURLPrefix)
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	ctx := context.Background()
+	for _, key := range []string{
+		"books/1/cover/a.png",
+		"books/1/images/b.png",
+		"materials/1/attachments/c",
+	} {
+		if err := store.Put(ctx, key, strings.NewReader(key), int64(len(key)), "application/octet-stream"); err != nil {
+			t.Fatalf("Put(%s): %v", key, err)
+		}
+	}
+
+	handler := http.StripPrefix(LocalURLPrefix, store.PublicHandler())
+	tests := []struct {
+		path string
+		want int
+	}{
+		{path: "/uploads/books/1/cover/a.png", want: http.StatusOK},
+		{path: "/uploads/books/1/images/b.png", want: http.StatusOK},
+		// 添付ファイルやディレクトリは配信しない
+		{path: "/uploads/materials/1/attachments/c", want: http.StatusNotFound},
+		{path: "/uploads/books/1/cover/", want: http.StatusNotFound},
+		{path: "/uploads/books/1/", want: http.StatusNotFound},
+		{path: "/uploads/books/1/cover/missing.png", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("GET %s: status = %d, want %d", tt.path, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"challecara2025-back/internal/config"
+)
+
+// fakeS3 S3互換APIのうちS3Storageが使う操作だけを実装したメモリ上のサーバー（署名は検証しない）
+type fakeS3 struct {
+	mu      sync.Mutex
+	buckets map[string]bool
+	objects map[string]fakeObject // キーは "bucket/key"
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	fake := &fakeS3{buckets: map[string]bool{}, objects: map[string]fakeObject{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if key == "" {
+		switch r.Method {
+		case http.MethodHead:
+			if !f.buckets[bucket] {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPut:
+			f.buckets[bucket] = true
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+		return
+	}
+	if !f.buckets[bucket] {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	name := bucket + "/" + key
+	switch r.Method {
+	case http.MethodPut:
+		data, err := readS3Body(r)
+		if err != nil {
+			writeS3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[name] = fakeObject{data: data, contentType: r.Header.Get("Content-Type")}
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodHead, http.MethodGet:
+		object, ok := f.objects[name]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.data)))
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			w.Write(object.data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func (f *fakeS3) object(bucket, key string) (fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	object, ok := f.objects[bucket+"/"+key]
+	return object, ok
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
+
+// readS3Body PUTの本文を読み取る（署名付きのaws-chunked形式の場合はチャンクを連結する）
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data bytes.Buffer
+	reader := bufio.NewReader(r.Body)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			break
+		}
+		if _, err := io.CopyN(&data, reader, size); err != nil {
+			return nil, err
+		}
+		if _, err := reader.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+	if want := r.Header.Get("X-Amz-Decoded-Content-Length"); want != "" && want != strconv.Itoa(data.Len()) {
+		return nil, fmt.Errorf("decoded length %d, want %s", data.Len(), want)
+	}
+	return data.Bytes(), nil
+}
+
+func newTestS3Storage(t *testing.T, server *httptest.Server, publicURL string) *S3Storage {
+	t.Helper()
+	store, err := NewS3Storage(config.S3{
+		Endpoint:        strings.TrimPrefix(server.URL, "http://"),
+		Bucket:          "uploads",
+		Region:          "us-east-1",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+	}, publicURL)
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+	return store
+}
+
+func TestS3Storage(t *testing.T) {
+	fake, server := newFakeS3(t)
+	store := newTestS3Storage(t, server, "")
+	ctx := context.Background()
+
+	fake.mu.Lock()
+	created := fake.buckets["uploads"]
+	fake.mu.Unlock()
+	if !created {
+		t.Fatal("bucket was not created")
+	}
+
+	content := []byte("cover image")
+	if err := store.Put(ctx, "books/1/cover/a.png", bytes.NewReader(content), int64(len(content)), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	object, ok := fake.object("uploads", "books/1/cover/a.png")
+	if !ok || !bytes.Equal(object.data, content) || object.contentType != "image/png" {
+		t.Fatalf("stored object = %+v, want %q as image/png", object, content)
+	}
+
+	reader, err := store.Get(ctx, "books/1/cover/a.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || !bytes.Equal(got, content) {
+		t.Errorf("Get = %q, %v, want %q", got, err, content)
+	}
+
+	if want := server.URL + "/uploads/books/1/cover/a.png"; store.URL("books/1/cover/a.png") != want {
+		t.Errorf("URL = %q, want %q", store.URL("books/1/cover/a.png"), want)
+	}
+
+	if err := store.Delete(ctx, "books/1/cover/a.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, "books/1/cover/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestS3StorageRejectsInvalidKeys(t *testing.T) {
+	_, server := newFakeS3(t)
+	store := newTestS3Storage(t, server, "https://cdn.example.com/")
+
+	if err := store.Put(context.Background(), "../secret", strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put(../secret): err = %v, want ErrInvalidKey", err)
+	}
+	if got, want := store.URL("books/1/images/a.png"), "https://cdn.example.com/books/1/images/a.png"; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"challecara2025-back/internal/config"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Storage アップロードされたファイルを保存するバックエンド
+type Storage interface {
+	// Put keyにファイルを保存する（既にある場合は上書き）
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get keyのファイルを読み出す。存在しない場合はErrNotFoundを返す
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete keyのファイルを削除する（存在しない場合もエラーにしない）
+	Delete(ctx context.Context, key string) error
+	// URL keyのファイルを配信するURLを返す
+	URL(key string) string
+}
+
+// New 設定の STORAGE_DRIVER（local または s3）に応じたストレージを作成
+func New(cfg config.Storage) (Storage, error) {
+	switch cfg.Driver {
+	case config.StorageLocal:
+		baseURL := cfg.PublicURL
+		if baseURL == "" {
+			baseURL = LocalURLPrefix
+		}
+		return NewLocalStorage(cfg.LocalDir, baseURL)
+	case config.StorageS3:
+		return NewS3Storage(cfg.S3, cfg.PublicURL)
+	}
+	return nil, fmt.Errorf("unknown storage driver: %s", cfg.Driver)
+}
+
+// IsPublicKey 公開URLでそのまま配信してよいオブジェクト（表紙・挿絵）のキーかどうか。
+// 添付ファイルは削除済みのものを返さないよう、ダウンロードAPI経由でのみ返す
+func IsPublicKey(key string) bool {
+	parts := strings.Split(key, "/")
+	if len(parts) != 4 || parts[0] != "books" || parts[3] == "" {
+		return false
+	}
+	return parts[2] == "cover" || parts[2] == "images"
+}
+
+// cleanKey keyを正規化し、ディレクトリの外を指すものを拒否する
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != strings.TrimPrefix(key, "/") || strings.Contains(cleaned, "..") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,88 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"challecara2025-back/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	gormtracing "gorm.io/plugin/opentelemetry/tracing"
+)
+
+// TraceIDHeader レスポンスでトレースIDを返すヘッダー
+const TraceIDHeader = "X-Trace-ID"
+
+// Setup 設定に応じたTracerProviderを作成し、グローバルに設定する。
+// 停止時には返したTracerProviderのShutdownで未送信のスパンを送信する
+func Setup(ctx context.Context, cfg config.Tracing) (*sdktrace.TracerProvider, error) {
+	var exporter sdktrace.SpanExporter
+	if cfg.Exporter == "otlp" {
+		var options []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+		}
+		var err error
+		if exporter, err = otlptracehttp.New(ctx, options...); err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+	}
+
+	provider, err := NewProvider(cfg, exporter)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider, nil
+}
+
+// NewProvider exporterに送信するTracerProviderを作成する。
+// exporterがnilの場合はスパンを記録するだけで送信しない（テストではメモリ上のexporterを渡す）
+func NewProvider(cfg config.Tracing, exporter sdktrace.SpanExporter) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(),
+		resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+	if exporter != nil {
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+	return sdktrace.NewTracerProvider(options...), nil
+}
+
+// Middleware リクエストごとにルートのテンプレート名のスパンを作成し、トレースIDをレスポンスのヘッダーで返す
+func Middleware(serviceName string, provider trace.TracerProvider) []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		otelgin.Middleware(serviceName, otelgin.WithTracerProvider(provider)),
+		func(c *gin.Context) {
+			if span := trace.SpanContextFromContext(c.Request.Context()); span.HasTraceID() {
+				c.Header(TraceIDHeader, span.TraceID().String())
+			}
+			c.Next()
+		},
+	}
+}
+
+// InstrumentDB dbのクエリをリクエストのスパンの子スパンとして記録する（値はスパンに含めない）
+func InstrumentDB(db *gorm.DB, provider trace.TracerProvider) error {
+	return db.Use(gormtracing.NewPlugin(
+		gormtracing.WithTracerProvider(provider),
+		gormtracing.WithoutMetrics(),
+		gormtracing.WithoutQueryVariables(),
+	))
+}
@@ -0,0 +1,87 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"challecara2025-back/internal/config"
+	"challecara2025-back/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddlewareRecordsRequestAndQuerySpans(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider, err := NewProvider(config.Tracing{ServiceName: "test", SampleRatio: 1}, exporter)
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	db, err := database.OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := InstrumentDB(db, provider); err != nil {
+		t.Fatalf("InstrumentDB: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(Middleware("test", provider)...)
+	router.GET("/books/:id", func(c *gin.Context) {
+		var n int
+		if err := db.WithContext(c.Request.Context()).Raw("SELECT 1").Scan(&n).Error; err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("ForceFlush: %v", err)
+	}
+
+	var server, query *tracetest.SpanStub
+	spans := exporter.GetSpans()
+	for i := range spans {
+		switch spans[i].SpanKind {
+		case trace.SpanKindServer:
+			server = &spans[i]
+		case trace.SpanKindClient:
+			query = &spans[i]
+		}
+	}
+	if server == nil {
+		t.Fatalf("no server span in %d spans", len(spans))
+	}
+	if query == nil {
+		t.Fatalf("no query span in %d spans", len(spans))
+	}
+
+	// スパン名にはIDではなくルートのテンプレートを使う
+	if server.Name != "GET /books/:id" {
+		t.Errorf("server span name = %q, want %q", server.Name, "GET /books/:id")
+	}
+	if query.SpanContext.TraceID() != server.SpanContext.TraceID() || query.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("query span parent = %s, want server span %s", query.Parent.SpanID(), server.SpanContext.SpanID())
+	}
+	if got, want := rec.Header().Get(TraceIDHeader), server.SpanContext.TraceID().String(); got != want {
+		t.Errorf("%s = %q, want %q", TraceIDHeader, got, want)
+	}
+}